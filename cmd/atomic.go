@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/vvfock3r/mysqlexport/kernel/module/logger"
+)
+
+// overwrite 是否允许覆盖已经存在的输出文件
+var overwrite bool
+
+// tempFiles 还没有重命名为目标文件的临时文件,出错时删除
+var tempFiles = make(map[string]bool)
+
+// partialOutput 导出出错时为true,已经写入的数据保存为 输出文件.partial,不会使用输出文件的名称
+// 收到信号中断导出时仍然保存为输出文件,并在输出中标记为不完整
+var partialOutput bool
+
+// partialExt 导出出错时保存的不完整的文件的扩展名
+const partialExt = ".partial"
+
+func init() {
+	// 出错退出之前删除临时文件
+	logger.OnFatal(removeTempFiles)
+}
+
+// checkOverwrite 检查输出文件是否已经存在,没有指定--overwrite时不允许覆盖
+// 从检查点继续导出时,检查点之后的文件会重新生成,同样允许覆盖
+func checkOverwrite(output string) error {
+	if overwrite || resume.Overwritable(output) {
+		return nil
+	}
+	_, err := os.Stat(output)
+	if err == nil {
+		return fmt.Errorf("the output file already exists: %s, use --overwrite to overwrite it", output)
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// createTemp 在输出文件所在的目录中新建临时文件,写入完成后由commitTemp重命名为输出文件
+// 在同一个目录中重命名是原子操作,程序中途退出时不会留下只写入了一半的输出文件
+// 不使用os.CreateTemp,它创建的文件权限固定为0600,这里与os.Create一样使用0666并受umask影响
+func createTemp(output string) (*os.File, error) {
+	dir, base := filepath.Split(output)
+	for i := 0; i < 10000; i++ {
+		name := filepath.Join(dir, "."+base+"."+strconv.FormatUint(uint64(rand.Uint32()), 10)+".tmp")
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		tempFiles[file.Name()] = true
+		return file, nil
+	}
+	return nil, fmt.Errorf("create temp file for %s error: too many attempts", output)
+}
+
+// removeTemp 删除不再需要的临时文件
+func removeTemp(temp string) {
+	_ = os.Remove(temp)
+	delete(tempFiles, temp)
+}
+
+// commitTemp 将已经关闭的临时文件重命名为输出文件,导出出错时重命名为 输出文件.partial
+func commitTemp(temp, output string) error {
+	if partialOutput {
+		return renameTemp(temp, output+partialExt)
+	}
+	err := checkOverwrite(output)
+	if err != nil {
+		return err
+	}
+	return renameTemp(temp, output)
+}
+
+// renameTemp 将已经关闭的临时文件重命名为输出文件,不检查输出文件是否存在
+func renameTemp(temp, output string) error {
+	err := os.Rename(temp, output)
+	if err != nil {
+		return err
+	}
+	delete(tempFiles, temp)
+	return nil
+}
+
+// removeTempFiles 删除所有还没有重命名的临时文件,出错退出之前调用
+func removeTempFiles() {
+	for temp := range tempFiles {
+		_ = os.Remove(temp)
+		delete(tempFiles, temp)
+	}
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yeka/zip"
+
+	"github.com/vvfock3r/mysqlexport/kernel/module/mysql"
+)
+
+// OutputFile 生成的文件,记录在清单中
+type OutputFile struct {
+	Name   string `json:"name"`
+	Rows   int    `json:"rows"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+
+	path string // 文件路径
+}
+
+// Manifest 打包时生成的清单文件
+type Manifest struct {
+	SQL       string        `json:"sql"`
+	Format    string        `json:"format"`
+	CreatedAt string        `json:"created_at"`
+	TotalRows int           `json:"total_rows"`
+	Files     []*OutputFile `json:"files"`
+
+	Snapshot *mysql.BinlogPosition `json:"snapshot,omitempty"` // 一致性快照对应的binlog位置
+}
+
+// Bundle 将生成的所有文件和清单打包为一个压缩包
+type Bundle struct {
+	// flags
+	format   string // 打包格式,目前仅支持zip
+	password string // 压缩包密码,使用AES-256加密
+
+	output string        // 压缩包名称
+	files  []*OutputFile // 生成的文件
+}
+
+func NewBundle() *Bundle {
+	return &Bundle{}
+}
+
+func (b *Bundle) Init() error {
+	if b.format == "" {
+		if b.password != "" {
+			return fmt.Errorf("the --bundle-password must be used with --bundle")
+		}
+		return nil
+	}
+	if b.format != "zip" {
+		return fmt.Errorf("unsupported bundle: %s, supported values: zip", b.format)
+	}
+	if isStdout(excel.output) {
+		return fmt.Errorf("the --bundle cannot be used when writing to stdout")
+	}
+
+	// 压缩包名称: 测试.xlsx => 测试.zip
+	output := trimCompressExt(excel.output)
+	b.output = strings.TrimSuffix(output, filepath.Ext(output)) + ".zip"
+
+	// 输出文件与压缩包同名时,打包成功后删除原文件会删除压缩包本身
+	if filepath.Clean(excel.output) == filepath.Clean(b.output) {
+		return fmt.Errorf("the --bundle cannot be used when the output file is %s, it is the same as the bundle", b.output)
+	}
+
+	return nil
+}
+
+// Add 记录生成的文件,每个文件保存后调用
+func (b *Bundle) Add(path string, rows int) {
+	b.files = append(b.files, &OutputFile{Name: filepath.Base(path), Rows: rows, path: path})
+}
+
+// Close 打包所有文件和清单,成功后删除原文件
+func (b *Bundle) Close() error {
+	if b.format == "" {
+		return nil
+	}
+
+	file, err := createTemp(b.output)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	zw := zip.NewWriter(file)
+
+	manifest := &Manifest{
+		SQL:       my.SQL(),
+		CreatedAt: time.Now().Format(time.RFC3339),
+		Files:     b.files,
+		Snapshot:  mysql.Snapshot,
+	}
+	manifest.Format, err = getFormat()
+	if err != nil {
+		return err
+	}
+
+	// 添加文件,同时计算大小和校验和
+	for _, f := range b.files {
+		w, err := b.create(zw, f.Name)
+		if err != nil {
+			return err
+		}
+
+		err = b.copyFile(w, f)
+		if err != nil {
+			return err
+		}
+		manifest.TotalRows += f.Rows
+	}
+
+	// 添加清单
+	w, err := b.create(zw, "manifest.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	err = encoder.Encode(manifest)
+	if err != nil {
+		return err
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	err = commitTemp(file.Name(), b.output)
+	if err != nil {
+		return err
+	}
+
+	// 删除原文件
+	for _, f := range b.files {
+		err = os.Remove(f.path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// create 在压缩包中新建一个文件,指定了密码时使用AES-256加密
+func (b *Bundle) create(zw *zip.Writer, name string) (io.Writer, error) {
+	fh := &zip.FileHeader{
+		Name:   name,
+		Method: zip.Deflate,
+	}
+	fh.SetModTime(time.Now())
+	if b.password != "" {
+		fh.SetPassword(b.password)
+		fh.SetEncryptionMethod(zip.AES256Encryption)
+	}
+	return zw.CreateHeader(fh)
+}
+
+func (b *Bundle) copyFile(w io.Writer, f *OutputFile) error {
+	src, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	hash := sha256.New()
+	f.Size, err = io.Copy(io.MultiWriter(w, hash), src)
+	if err != nil {
+		return err
+	}
+	f.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	return nil
+}
@@ -0,0 +1,462 @@
+package cmd
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"github.com/vvfock3r/mysqlexport/kernel/module/logger"
+	"github.com/vvfock3r/mysqlexport/kernel/module/mysql"
+)
+
+// columnMeta information_schema.COLUMNS中ENUM、SET、BIT列的定义
+// MySQL返回的ENUM和SET的列类型为CHAR,BIT也不包含位数,只能通过表结构获取
+type columnMeta struct {
+	Schema     string `db:"table_schema"`
+	Table      string `db:"table_name"`
+	Name       string `db:"column_name"`
+	DataType   string `db:"data_type"`   // enum、set、bit
+	ColumnType string `db:"column_type"` // 比如 enum('a','b')、bit(1)
+
+	values []string // ENUM和SET允许的值
+	bits   int      // BIT的位数
+}
+
+// queryColumns 一条SQL中的表、查询的列以及表中ENUM、SET、BIT列的定义
+type queryColumns struct {
+	tables []tableRef
+	items  []selectItem             // 查询的列,无法确定结果中的列对应的原始列时为nil
+	metas  map[string][]*columnMeta // 键为小写的列名称
+}
+
+// tableRef SQL中FROM、JOIN、TABLE之后的表
+type tableRef struct {
+	schema string // 未指定数据库时为空
+	name   string
+	alias  string // 没有别名时为空
+}
+
+// selectItem SELECT中的一列,只记录直接查询的列,比如 o.status AS s;表达式等计算列的column为空
+type selectItem struct {
+	table  string // 列名称之前的表名称或者别名,没有指定时为空
+	column string // 原始列名称
+	star   bool   // * 或者 表.*
+}
+
+// tableRefKeywords 表名称之后可能出现的关键字,不是别名
+var tableRefKeywords = []string{
+	"WHERE", "JOIN", "INNER", "LEFT", "RIGHT", "CROSS", "NATURAL", "STRAIGHT_JOIN", "ON", "USING",
+	"GROUP", "HAVING", "ORDER", "LIMIT", "UNION", "EXCEPT", "INTERSECT", "WINDOW", "FOR", "LOCK",
+	"INTO", "PARTITION", "USE", "IGNORE", "FORCE",
+}
+
+// selectModifiers SELECT之后、查询的列之前可能出现的关键字
+var selectModifiers = []string{
+	"ALL", "DISTINCT", "DISTINCTROW", "HIGH_PRIORITY", "STRAIGHT_JOIN", "SQL_SMALL_RESULT", "SQL_BIG_RESULT",
+	"SQL_BUFFER_RESULT", "SQL_NO_CACHE", "SQL_CALC_FOUND_ROWS",
+}
+
+// queryTables 获取SQL中FROM、JOIN和TABLE之后的表,包括 FROM a JOIN b ON ..., c 中ON、USING之后以逗号分隔的表
+// 只是为了查询ENUM、SET、BIT的定义,子查询和CTE的名称同样会被返回,查询不到时忽略
+func queryTables(tokens []token) []tableRef {
+	// tableName 解析第i个词法单元开始的表名称,返回表名称和下一个词法单元的位置
+	tableName := func(i int) (tableRef, int, bool) {
+		if i >= len(tokens) || !isNameToken(tokens[i]) || tokens[i].is("SELECT") || tokens[i].is("LATERAL") || tokens[i].is("DUAL") {
+			return tableRef{}, i, false
+		}
+		if i+2 < len(tokens) && tokens[i+1].is(".") && isNameToken(tokens[i+2]) {
+			return tableRef{schema: unquoteIdent(tokens[i].text), name: unquoteIdent(tokens[i+2].text)}, i + 3, true
+		}
+		return tableRef{name: unquoteIdent(tokens[i].text)}, i + 1, true
+	}
+
+	var tables []tableRef
+	for i := range tokens {
+		if !tokens[i].is("FROM") && !tokens[i].is("JOIN") && !tokens[i].is("STRAIGHT_JOIN") && !tokens[i].is("TABLE") {
+			continue
+		}
+
+		// FROM a AS x, b y 逗号分隔的多个表
+		j := i + 1
+		for {
+			table, next, ok := tableName(j)
+			if !ok {
+				break
+			}
+
+			// 别名
+			j = next
+			if j+1 < len(tokens) && tokens[j].is("AS") {
+				table.alias = unquoteIdent(tokens[j+1].text)
+				j += 2
+			} else if j < len(tokens) && (tokens[j].kind == tokenIdent || tokens[j].kind == tokenWord && !isTableRefKeyword(tokens[j])) {
+				table.alias = unquoteIdent(tokens[j].text)
+				j++
+			}
+			tables = append(tables, table)
+
+			// 跳过ON、USING的连接条件,之后可能还有逗号分隔的表
+			if j < len(tokens) && (tokens[j].is("ON") || tokens[j].is("USING")) {
+				depth := 0
+				for j++; j < len(tokens); j++ {
+					if tokens[j].is("(") {
+						depth++
+					} else if tokens[j].is(")") {
+						depth--
+					}
+					if depth < 0 || depth == 0 && (tokens[j].is(",") || isTableRefKeyword(tokens[j])) {
+						break
+					}
+				}
+			}
+			if j >= len(tokens) || !tokens[j].is(",") {
+				break
+			}
+			j++
+		}
+	}
+	return tables
+}
+
+// selectItems 解析 SELECT ... FROM 之间查询的列,TABLE语句为 *
+// 只支持一个SELECT,包含子查询、UNION、CTE等时无法确定结果中的列对应的原始列,返回false
+func selectItems(tokens []token) ([]selectItem, bool) {
+	if len(tokens) == 0 {
+		return nil, false
+	}
+	if tokens[0].is("TABLE") {
+		return []selectItem{{star: true}}, true
+	}
+	if !tokens[0].is("SELECT") {
+		return nil, false
+	}
+	for _, t := range tokens[1:] {
+		if t.is("SELECT") {
+			return nil, false
+		}
+	}
+
+	i := 1
+	for i < len(tokens) && inKeywords(tokens[i], selectModifiers) {
+		i++
+	}
+
+	// 按照顶层的逗号拆分,直到FROM
+	var items []selectItem
+	var item []token
+	depth := 0
+	for ; i <= len(tokens); i++ {
+		end := i == len(tokens) || depth == 0 && (tokens[i].is(",") || tokens[i].is("FROM"))
+		if end {
+			if len(item) == 0 {
+				return nil, false
+			}
+			items = append(items, parseSelectItem(item))
+			item = nil
+			if i == len(tokens) || !tokens[i].is(",") {
+				break
+			}
+			continue
+		}
+		if tokens[i].is("(") {
+			depth++
+		} else if tokens[i].is(")") {
+			depth--
+		}
+		item = append(item, tokens[i])
+	}
+	return items, true
+}
+
+// parseSelectItem 解析查询的一列: *、表.*、列、表.列、数据库.表.列,后面可以有别名,其他为计算列
+func parseSelectItem(item []token) selectItem {
+	// 去掉别名
+	if n := len(item); n >= 3 && item[n-2].is("AS") {
+		item = item[:n-2]
+	} else if n >= 2 && (isNameToken(item[n-1]) || item[n-1].kind == tokenString) && isNameToken(item[n-2]) {
+		item = item[:n-1]
+	}
+
+	switch {
+	case len(item) == 1 && item[0].is("*"):
+		return selectItem{star: true}
+	case len(item) == 3 && isNameToken(item[0]) && item[1].is(".") && item[2].is("*"):
+		return selectItem{table: unquoteIdent(item[0].text), star: true}
+	case len(item) == 1 && isNameToken(item[0]):
+		return selectItem{column: unquoteIdent(item[0].text)}
+	case len(item) == 3 && isNameToken(item[0]) && item[1].is(".") && isNameToken(item[2]):
+		return selectItem{table: unquoteIdent(item[0].text), column: unquoteIdent(item[2].text)}
+	case len(item) == 5 && isNameToken(item[0]) && item[1].is(".") && isNameToken(item[2]) && item[3].is(".") && isNameToken(item[4]):
+		return selectItem{table: unquoteIdent(item[2].text), column: unquoteIdent(item[4].text)}
+	}
+	return selectItem{}
+}
+
+// isNameToken 是否可以作为表名称或者列名称,不包括数字、NULL、TRUE、FALSE
+func isNameToken(t token) bool {
+	if t.kind == tokenIdent {
+		return true
+	}
+	if t.kind != tokenWord || t.text[0] >= '0' && t.text[0] <= '9' {
+		return false
+	}
+	return !t.is("NULL") && !t.is("TRUE") && !t.is("FALSE")
+}
+
+func isTableRefKeyword(t token) bool {
+	return inKeywords(t, tableRefKeywords)
+}
+
+func inKeywords(t token, keywords []string) bool {
+	for _, keyword := range keywords {
+		if t.is(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// unquoteIdent 去掉标识符的反引号
+func unquoteIdent(text string) string {
+	if len(text) >= 2 && text[0] == '`' && text[len(text)-1] == '`' {
+		return strings.ReplaceAll(text[1:len(text)-1], "``", "`")
+	}
+	return text
+}
+
+// lookupColumnMetas 解析当前SQL查询的表和列,并查询表中ENUM、SET、BIT列的定义
+// 必须在执行查询之前调用,一致性快照的专用连接在读取结果时不能执行其他查询;查询失败时只打印警告
+func (m *MySQL) lookupColumnMetas(ctx context.Context) *queryColumns {
+	if q, ok := m.columnMetaCache[m.execute]; ok {
+		return q
+	}
+
+	tokens := tokenize(m.execute)
+	for len(tokens) > 0 && tokens[len(tokens)-1].is(";") {
+		tokens = tokens[:len(tokens)-1]
+	}
+	q := &queryColumns{tables: queryTables(tokens), metas: make(map[string][]*columnMeta)}
+	if items, ok := selectItems(tokens); ok {
+		q.items = items
+	}
+	if m.columnMetaCache == nil {
+		m.columnMetaCache = make(map[string]*queryColumns)
+	}
+	m.columnMetaCache[m.execute] = q
+	if len(q.tables) == 0 || q.items == nil {
+		return q
+	}
+
+	var conditions []string
+	var args []any
+	for _, table := range q.tables {
+		if table.schema == "" {
+			conditions = append(conditions, "(TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?)")
+			args = append(args, table.name)
+		} else {
+			conditions = append(conditions, "(TABLE_SCHEMA = ? AND TABLE_NAME = ?)")
+			args = append(args, table.schema, table.name)
+		}
+	}
+	query := "SELECT TABLE_SCHEMA AS table_schema, TABLE_NAME AS table_name, COLUMN_NAME AS column_name, " +
+		"DATA_TYPE AS data_type, COLUMN_TYPE AS column_type FROM information_schema.COLUMNS " +
+		"WHERE DATA_TYPE IN ('enum', 'set', 'bit') AND (" + strings.Join(conditions, " OR ") + ")"
+
+	var list []*columnMeta
+	err := sqlx.SelectContext(ctx, mysql.Queryer(), &list, query, args...)
+	if err != nil {
+		logger.Warn("query the ENUM, SET and BIT columns from information_schema error", zap.Error(err))
+		return q
+	}
+
+	for _, meta := range list {
+		meta.DataType = strings.ToLower(meta.DataType)
+		if meta.DataType == "bit" {
+			meta.bits, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(meta.ColumnType), "bit("), ")"))
+		} else {
+			meta.values = parseEnumValues(meta.ColumnType)
+		}
+		name := strings.ToLower(meta.Name)
+		q.metas[name] = append(q.metas[name], meta)
+	}
+	return q
+}
+
+// parseEnumValues 解析ENUM和SET允许的值,比如 enum('a','b') 返回 a、b
+func parseEnumValues(columnType string) []string {
+	start := strings.IndexByte(columnType, '(')
+	end := strings.LastIndexByte(columnType, ')')
+	if start < 0 || end < start {
+		return nil
+	}
+	text := columnType[start+1 : end]
+
+	var values []string
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\'' {
+			continue
+		}
+		var b strings.Builder
+		for i++; i < len(text); i++ {
+			if text[i] == '\'' {
+				// 两个单引号代表一个单引号
+				if i+1 < len(text) && text[i+1] == '\'' {
+					b.WriteByte('\'')
+					i++
+					continue
+				}
+				// 后面是逗号或者结尾时值才结束,兼容没有转义单引号的实现
+				if i+1 == len(text) || text[i+1] == ',' {
+					break
+				}
+			}
+			b.WriteByte(text[i])
+		}
+		values = append(values, b.String())
+	}
+	return values
+}
+
+// resultItems 结果中的每一列对应的查询的列,* 展开为多列,无法确定时返回nil
+// 只有一个 * 时根据结果的列数计算展开的列数,有多个 * 时所有查询的列都必须是 *
+func (q *queryColumns) resultItems(n int) []selectItem {
+	stars := 0
+	for _, item := range q.items {
+		if item.star {
+			stars++
+		}
+	}
+	others := len(q.items) - stars
+	switch {
+	case q.items == nil, stars == 0 && others != n, others > n, stars > 1 && others > 0:
+		return nil
+	case stars > 1:
+		// 无法确定每一列来自哪个表,按照列名称匹配所有表
+		items := make([]selectItem, n)
+		for i := range items {
+			items[i].star = true
+		}
+		return items
+	}
+
+	var items []selectItem
+	for _, item := range q.items {
+		if !item.star {
+			items = append(items, item)
+			continue
+		}
+		for j := 0; j < n-others; j++ {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// matchTable 列名称之前的表名称或者别名是否指向列的定义所在的表
+func (q *queryColumns) matchTable(table string, meta *columnMeta) bool {
+	for _, t := range q.tables {
+		name := t.alias
+		if name == "" {
+			name = t.name
+		}
+		if !strings.EqualFold(name, table) || !strings.EqualFold(t.name, meta.Table) {
+			continue
+		}
+		if t.schema == "" || strings.EqualFold(t.schema, meta.Schema) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveColumnMetas 按照结果中每一列对应的原始列匹配列的定义,别名不影响匹配,表达式等计算列不做处理
+// 列名称之前指定了表时只匹配该表,否则匹配所有表中的同名列,存在同名但定义不同的列时无法确定,不做处理
+func (m *MySQL) resolveColumnMetas(q *queryColumns) {
+	m.columnMetas = make([]*columnMeta, len(m.columnNames))
+	if q == nil {
+		return
+	}
+	for i, item := range q.resultItems(len(m.columnNames)) {
+		// * 展开的列,结果中的列名称就是原始列名称
+		column := item.column
+		if item.star {
+			column = m.columnNames[i]
+		}
+		if column == "" {
+			continue
+		}
+
+		var meta *columnMeta
+		ambiguous := false
+		for _, other := range q.metas[strings.ToLower(column)] {
+			if item.table != "" && !q.matchTable(item.table, other) {
+				continue
+			}
+			if meta != nil && !strings.EqualFold(other.ColumnType, meta.ColumnType) {
+				ambiguous = true
+				break
+			}
+			meta = other
+		}
+		if meta == nil || ambiguous {
+			continue
+		}
+
+		// 类型必须一致
+		switch kind := m.columnKinds[i]; {
+		case meta.DataType == "bit" && kind == kindBit:
+		case meta.DataType == "enum" && (kind == kindString || kind == kindEnum):
+			m.columnKinds[i] = kindEnum
+		case meta.DataType == "set" && (kind == kindString || kind == kindSet):
+			m.columnKinds[i] = kindSet
+		default:
+			continue
+		}
+		m.columnMetas[i] = meta
+	}
+}
+
+// oneHot 第i列是否展开为多列,--set-format onehot时SET的每个值展开为一列,无法解析允许的值时不展开
+func (m *MySQL) oneHot(i int) bool {
+	return m.setFormat == "onehot" && m.columnKinds[i] == kindSet && m.columnMetas[i] != nil && len(m.columnMetas[i].values) > 0
+}
+
+// setCellColumns 计算电子表格中每个单元格对应的列
+func (m *MySQL) setCellColumns() {
+	m.cellColumns = nil
+	for i := range m.columnNames {
+		n := 1
+		if m.oneHot(i) {
+			n = len(m.columnMetas[i].values)
+		}
+		for j := 0; j < n; j++ {
+			m.cellColumns = append(m.cellColumns, i)
+		}
+	}
+}
+
+// cellColumn 电子表格中第i个单元格(从0开始)对应的列,SET展开为多列时多个单元格对应同一列,超出范围时返回-1
+func (m *MySQL) cellColumn(i int) int {
+	if i < 0 || i >= len(m.cellColumns) {
+		return -1
+	}
+	return m.cellColumns[i]
+}
+
+// cellNames 电子表格的表头,SET展开为多列时列名称为 列名称(值),比如 tags(red)
+func (m *MySQL) cellNames(names []string) []string {
+	var list []string
+	for i, name := range names {
+		if i < len(m.columnKinds) && m.oneHot(i) {
+			for _, value := range m.columnMetas[i].values {
+				list = append(list, name+"("+value+")")
+			}
+			continue
+		}
+		list = append(list, name)
+	}
+	return list
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+type CSV struct {
+	// flags
+	delimiter  string // 字段分隔符,csv默认为逗号,tsv默认为制表符
+	quote      string // 引号字符
+	lineEnding string // 换行符,lf或crlf
+	bom        bool   // 是否写入UTF-8 BOM,Windows下的Excel需要BOM才能正确识别编码
+
+	// 与Excel共用的flags
+	output      string // 输出文件
+	maxFileLine int    // 每个文件最多允许写入多少行，不包含表头
+
+	// 存储flags解析结果
+	comma   rune
+	quoteCh rune
+	newline string
+
+	header []string
+	file   *TextFile
+}
+
+func NewCSV() *CSV {
+	return &CSV{}
+}
+
+// Init 解析flags,format为csv或tsv
+func (c *CSV) Init(format string) error {
+	// 分隔符
+	delimiter := c.delimiter
+	if delimiter == "" {
+		delimiter = ","
+		if format == "tsv" {
+			delimiter = "\t"
+		}
+	}
+	if delimiter == `\t` || delimiter == "tab" {
+		delimiter = "\t"
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return fmt.Errorf("the delimiter must be a single character: %s", c.delimiter)
+	}
+	c.comma, _ = utf8.DecodeRuneInString(delimiter)
+
+	// 引号
+	if utf8.RuneCountInString(c.quote) != 1 {
+		return fmt.Errorf("the quote must be a single character: %s", c.quote)
+	}
+	c.quoteCh, _ = utf8.DecodeRuneInString(c.quote)
+	if c.quoteCh == c.comma {
+		return fmt.Errorf("the quote and the delimiter cannot be the same character")
+	}
+
+	// 换行符
+	switch strings.ToLower(c.lineEnding) {
+	case "lf":
+		c.newline = "\n"
+	case "crlf":
+		c.newline = "\r\n"
+	default:
+		return fmt.Errorf("unrecognized line ending: %s, supported values: lf,crlf", c.lineEnding)
+	}
+
+	c.file = NewTextFile(c.output, c.maxFileLine)
+	c.file.onOpen = c.writeHeader
+
+	return nil
+}
+
+func (c *CSV) SetColumns(names []string, _ []*sql.ColumnType) error {
+	c.header = names
+	return c.file.Open()
+}
+
+func (c *CSV) WriteRow(row []any) error {
+	w, err := c.file.Next()
+	if err != nil {
+		return err
+	}
+	return c.writeRecord(w, my.ParseText(row))
+}
+
+func (c *CSV) WriteMarker(text string) error {
+	w, err := c.file.Writer()
+	if err != nil {
+		return err
+	}
+	return c.writeRecord(w, []string{text})
+}
+
+func (c *CSV) Close() error {
+	return c.file.Close()
+}
+
+// writeHeader 每个新文件都需要写入BOM和表头
+func (c *CSV) writeHeader(w io.Writer) error {
+	if c.bom {
+		_, err := io.WriteString(w, "\uFEFF")
+		if err != nil {
+			return err
+		}
+	}
+	if len(c.header) == 0 {
+		return nil
+	}
+	return c.writeRecord(w, c.header)
+}
+
+// writeRecord 按照RFC 4180写入一行
+func (c *CSV) writeRecord(w io.Writer, record []string) error {
+	var b strings.Builder
+	for i, field := range record {
+		if i > 0 {
+			b.WriteRune(c.comma)
+		}
+		if !c.fieldNeedsQuotes(field) {
+			b.WriteString(field)
+			continue
+		}
+
+		// 字段中的引号需要写两次
+		quote := string(c.quoteCh)
+		b.WriteString(quote)
+		b.WriteString(strings.ReplaceAll(field, quote, quote+quote))
+		b.WriteString(quote)
+	}
+	b.WriteString(c.newline)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// fieldNeedsQuotes 字段包含分隔符、引号或换行符时需要使用引号包裹
+func (c *CSV) fieldNeedsQuotes(field string) bool {
+	return strings.ContainsRune(field, c.comma) ||
+		strings.ContainsRune(field, c.quoteCh) ||
+		strings.ContainsAny(field, "\r\n")
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"github.com/vvfock3r/mysqlexport/kernel/module/logger"
+	"github.com/vvfock3r/mysqlexport/kernel/module/mysql"
+)
+
+// explainRefuseList --explain-refuse 支持的值
+var explainRefuseList = []string{"full-scan", "filesort", "temporary"}
+
+// Explain 执行前通过 EXPLAIN FORMAT=JSON 检查SQL的执行计划,超过阈值时拒绝执行,避免误操作导致全表扫描拖垮MySQL
+type Explain struct {
+	// flags
+	enable  bool   // 是否检查执行计划,指定了阈值时自动开启
+	maxRows int64  // 单个表预估扫描的最大行数,小于等于0代表不限制
+	refuse  string // 拒绝执行的情况,多个使用逗号分隔: full-scan、filesort、temporary
+	force   bool   // 超过阈值时仍然执行,只输出警告
+
+	refuseList []string // refuse解析结果
+}
+
+func NewExplain() *Explain {
+	return &Explain{}
+}
+
+func (e *Explain) Init() error {
+	e.refuseList = nil
+	for _, item := range strings.Split(e.refuse, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if !in(item, explainRefuseList) {
+			return fmt.Errorf("unsupported --explain-refuse: %s, supported values: %s", item, strings.Join(explainRefuseList, ", "))
+		}
+		e.refuseList = append(e.refuseList, item)
+	}
+
+	if e.maxRows > 0 || len(e.refuseList) > 0 {
+		e.enable = true
+	}
+	return nil
+}
+
+// Check 依次检查每条SQL的执行计划并输出到日志中,超过阈值且没有指定--force时返回错误
+func (e *Explain) Check(statements []Statement, params []Param) error {
+	err := e.Init()
+	if err != nil {
+		return err
+	}
+	if !e.enable {
+		return nil
+	}
+
+	for i, statement := range statements {
+		prefix := ""
+		if len(statements) > 1 {
+			prefix = fmt.Sprintf("sql statement %d: ", i+1)
+		}
+
+		// SHOW、DESCRIBE、EXPLAIN不读取表数据,也不支持EXPLAIN
+		tokens := tokenize(statement.SQL)
+		if len(tokens) > 0 && in(strings.ToUpper(tokens[0].text), []string{"SHOW", "DESCRIBE", "DESC", "EXPLAIN"}) {
+			continue
+		}
+
+		plan, err := e.explain(statement.SQL, params)
+		if err != nil {
+			return fmt.Errorf("%sexplain error: %w", prefix, err)
+		}
+
+		// 输出执行计划
+		logger.Info(prefix+"explain",
+			zap.Int64("estimated_rows", plan.Rows()),
+			zap.Bool("full_scan", plan.FullScan()),
+			zap.Bool("filesort", plan.Filesort),
+			zap.Bool("temporary", plan.Temporary),
+		)
+		for _, t := range plan.Tables {
+			logger.Info(prefix+"explain table",
+				zap.String("table", t.Name),
+				zap.String("access_type", t.AccessType),
+				zap.String("key", t.Key),
+				zap.Int64("rows", t.Rows),
+			)
+		}
+
+		// 检查阈值
+		reasons := e.reasons(plan)
+		if len(reasons) == 0 {
+			continue
+		}
+		if !e.force {
+			return fmt.Errorf("%sthe query is refused: %s, use --force to run it anyway", prefix, strings.Join(reasons, "; "))
+		}
+		for _, reason := range reasons {
+			logger.Warn(prefix + reason + ", but --force is specified")
+		}
+	}
+	return nil
+}
+
+// explain 执行 EXPLAIN FORMAT=JSON 并解析结果
+func (e *Explain) explain(query string, params []Param) (*explainPlan, error) {
+	query, args, err := bindParams(query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []byte
+	err = sqlx.GetContext(context.Background(), mysql.Queryer(), &result, "EXPLAIN FORMAT=JSON "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseExplain(result)
+}
+
+// reasons 返回超过阈值的原因
+func (e *Explain) reasons(plan *explainPlan) []string {
+	var reasons []string
+	for _, t := range plan.Tables {
+		if e.maxRows > 0 && t.Rows > e.maxRows {
+			reasons = append(reasons, fmt.Sprintf("table %s is estimated to examine %d rows, it exceeds the --explain-max-rows %d", t.Name, t.Rows, e.maxRows))
+		}
+		if in("full-scan", e.refuseList) && t.FullScan() {
+			reasons = append(reasons, fmt.Sprintf("table %s uses a full table scan", t.Name))
+		}
+	}
+	if in("filesort", e.refuseList) && plan.Filesort {
+		reasons = append(reasons, "the query uses a filesort")
+	}
+	if in("temporary", e.refuseList) && plan.Temporary {
+		reasons = append(reasons, "the query uses a temporary table")
+	}
+	return reasons
+}
+
+// explainTable 执行计划中对一个表的访问
+type explainTable struct {
+	Name       string
+	AccessType string // ALL、index、range、ref、eq_ref、const等
+	Key        string // 使用的索引
+	Rows       int64  // 预估每次扫描的行数
+}
+
+// FullScan 是否为全表扫描
+func (t explainTable) FullScan() bool {
+	return strings.EqualFold(t.AccessType, "ALL")
+}
+
+// explainPlan 执行计划摘要
+type explainPlan struct {
+	Tables    []explainTable
+	Filesort  bool // 是否使用了文件排序
+	Temporary bool // 是否使用了临时表
+}
+
+// Rows 所有表预估扫描的行数之和
+func (p *explainPlan) Rows() int64 {
+	var rows int64
+	for _, t := range p.Tables {
+		rows += t.Rows
+	}
+	return rows
+}
+
+// FullScan 是否有表使用了全表扫描
+func (p *explainPlan) FullScan() bool {
+	for _, t := range p.Tables {
+		if t.FullScan() {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExplain 解析 EXPLAIN FORMAT=JSON 的结果,兼容MySQL和MariaDB
+// 执行计划的结构随着连接、子查询、UNION等嵌套,所以递归查找所有的表访问、文件排序和临时表
+func parseExplain(data []byte) (*explainPlan, error) {
+	var v any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&v)
+	if err != nil {
+		return nil, fmt.Errorf("parse explain result error: %w", err)
+	}
+
+	plan := &explainPlan{}
+	plan.walk(v)
+	return plan, nil
+}
+
+func (p *explainPlan) walk(v any) {
+	switch value := v.(type) {
+	case []any:
+		for _, item := range value {
+			p.walk(item)
+		}
+	case map[string]any:
+		// 表访问: {"table_name": "users", "access_type": "ALL", "rows_examined_per_scan": 1000, ...}
+		name, _ := value["table_name"].(string)
+		accessType, _ := value["access_type"].(string)
+		if name != "" && accessType != "" {
+			key, _ := value["key"].(string)
+			p.Tables = append(p.Tables, explainTable{
+				Name:       name,
+				AccessType: accessType,
+				Key:        key,
+				Rows:       explainRows(value),
+			})
+		}
+
+		// 按照键名排序,保证输出的顺序固定
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			switch k {
+			// MySQL
+			case "using_filesort":
+				p.Filesort = p.Filesort || value[k] == true
+			case "using_temporary_table":
+				p.Temporary = p.Temporary || value[k] == true
+			// MariaDB
+			case "filesort":
+				p.Filesort = true
+			case "temporary_table":
+				p.Temporary = true
+			}
+			p.walk(value[k])
+		}
+	}
+}
+
+// explainRows 表访问预估扫描的行数,MySQL为rows_examined_per_scan,MariaDB为rows
+func explainRows(table map[string]any) int64 {
+	for _, key := range []string{"rows_examined_per_scan", "rows"} {
+		n, ok := table[key].(json.Number)
+		if !ok {
+			continue
+		}
+		if rows, err := n.Int64(); err == nil {
+			return rows
+		}
+		if rows, err := n.Float64(); err == nil {
+			return int64(rows)
+		}
+	}
+	return 0
+}
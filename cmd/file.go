@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// TextFile 文本类格式共用的文件写入器
+// 超过最大行数后会自动新建文件,命名规则与Excel工作簿一致: 名称-1.csv、名称-2.csv、...
+// 指定了--compress时每个文件会单独压缩,并添加对应的扩展名: 名称-1.csv.gz、名称-2.csv.gz、...
+type TextFile struct {
+	output    string // 输出文件,不包含压缩扩展名
+	compress  string // 压缩算法
+	maxLine   int    // 每个文件最多允许写入多少行，不包含表头
+	curLine   int    // 当前文件写入了多少行，不包含表头
+	totalLine int    // 当前总共写入了多少行，不包含表头
+
+	file *os.File
+	cw   io.WriteCloser // 压缩写入器
+	w    *bufio.Writer
+
+	onOpen  func(w io.Writer) error // 新文件打开后调用,一般用于写入BOM和表头
+	onClose func(w io.Writer) error // 文件关闭前调用,一般用于写入结尾
+}
+
+func NewTextFile(output string, maxLine int) *TextFile {
+	return &TextFile{
+		output:   output,
+		compress: compress,
+		maxLine:  maxLine,
+	}
+}
+
+// Open 打开一个新文件,已经打开时什么也不做
+func (t *TextFile) Open() error {
+	if t.file != nil {
+		return nil
+	}
+
+	file := os.Stdout
+	if !isStdout(t.output) {
+		var err error
+		file, err = createTemp(t.output + compressExtMap[t.compress])
+		if err != nil {
+			return err
+		}
+	}
+	t.file = file
+
+	// 压缩,统计的是实际写入文件的字节数
+	var w io.Writer = countWriter{w: file}
+	switch t.compress {
+	case "gzip":
+		t.cw = gzip.NewWriter(w)
+		w = t.cw
+	case "zstd":
+		encoder, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		t.cw = encoder
+		w = t.cw
+	}
+
+	t.w = bufio.NewWriter(w)
+	t.curLine = 0
+
+	if t.onOpen != nil {
+		return t.onOpen(t.w)
+	}
+	return nil
+}
+
+// Next 准备写入下一行,超过最大行数时切换到新文件
+func (t *TextFile) Next() (io.Writer, error) {
+	// 超过文件最大行数则重新建一个
+	if t.file != nil && t.maxLine > 0 && t.curLine+1 > t.maxLine {
+		err := t.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := t.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	// 计数加1
+	t.curLine++
+	t.totalLine++
+
+	return t.w, nil
+}
+
+// Writer 返回当前文件的写入器,写入的内容不计入行数,一般用于写入提示信息
+func (t *TextFile) Writer() (io.Writer, error) {
+	err := t.Open()
+	if err != nil {
+		return nil, err
+	}
+	return t.w, nil
+}
+
+// Close 关闭当前文件,多文件时按规则重命名
+func (t *TextFile) Close() error {
+	if t.file == nil {
+		return nil
+	}
+
+	if t.onClose != nil {
+		err := t.onClose(t.w)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := t.w.Flush()
+	if err != nil {
+		return err
+	}
+
+	if t.cw != nil {
+		err = t.cw.Close()
+		if err != nil {
+			return err
+		}
+		t.cw = nil
+	}
+
+	// 标准输出不需要关闭和重命名
+	if isStdout(t.output) {
+		t.file = nil
+		return nil
+	}
+
+	temp := t.file.Name()
+	err = t.file.Close()
+	if err != nil {
+		return err
+	}
+	t.file = nil
+
+	// 写入完成后将临时文件重命名为输出文件
+	output, err := partOutput(t.output, t.totalLine, t.maxLine)
+	if err != nil {
+		return err
+	}
+	ext := compressExtMap[t.compress]
+	err = commitTemp(temp, output+ext)
+	if err != nil {
+		return err
+	}
+
+	// 记录生成的文件
+	return addOutputFile(output+ext, t.curLine)
+}
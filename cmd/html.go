@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// htmlStyle 页面的基础样式,统计信息写在文件末尾,通过order显示在表格上方
+const htmlStyle = `body { display: flex; flex-direction: column; margin: 24px; font-family: -apple-system, "Segoe UI", "Microsoft YaHei", Arial, sans-serif; font-size: 14px; color: #333; }
+.summary { order: -1; margin-bottom: 16px; }
+.summary pre { margin: 0 0 8px; padding: 8px 12px; background: #f6f8fa; border: 1px solid #e1e4e8; white-space: pre-wrap; }
+.summary span { margin-right: 24px; color: #666; }
+table { border-collapse: collapse; }
+th, td { padding: 4px 8px; border: 1px solid #d0d7de; text-align: left; vertical-align: middle; }
+tr.marker td { color: #cf222e; font-weight: bold; }
+thead th { background: #f6f8fa; }
+`
+
+// HTML 输出为独立的HTML报表,样式flags转为CSS
+type HTML struct {
+	// 与Excel共用的flags
+	output      string // 输出文件
+	maxFileLine int    // 每个文件最多允许写入多少行
+
+	css     string   // 根据样式flags生成的CSS
+	header  []string // 表头
+	rowLine int      // 当前文件写入了多少行,包含表头
+	file    *TextFile
+}
+
+func NewHTML() *HTML {
+	return &HTML{}
+}
+
+func (h *HTML) Init() error {
+	err := excel.ParseStyle()
+	if err != nil {
+		return err
+	}
+	h.css = h.parseCSS()
+
+	h.file = NewTextFile(h.output, h.maxFileLine)
+	h.file.onOpen = h.writeHeader
+	h.file.onClose = h.writeFooter
+
+	return nil
+}
+
+func (h *HTML) SetColumns(names []string, _ []*sql.ColumnType) error {
+	h.header = names
+	return h.file.Open()
+}
+
+func (h *HTML) WriteRow(row []any) error {
+	w, err := h.file.Next()
+	if err != nil {
+		return err
+	}
+
+	h.rowLine++
+	_, err = io.WriteString(w, h.formatRow("td", h.rowLine, my.ParseText(row)))
+	return err
+}
+
+// WriteMarker 提示信息写为跨越所有列的一行
+func (h *HTML) WriteMarker(text string) error {
+	w, err := h.file.Writer()
+	if err != nil {
+		return err
+	}
+	colspan := strconv.Itoa(len(h.header))
+	_, err = io.WriteString(w, `<tr class="marker"><td colspan="`+colspan+`">`+html.EscapeString(text)+"</td></tr>\n")
+	return err
+}
+
+func (h *HTML) Close() error {
+	return h.file.Close()
+}
+
+// writeHeader 写入页面开头和表头
+func (h *HTML) writeHeader(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>" + html.EscapeString(filepath.Base(h.output)) + "</title>\n")
+	b.WriteString("<style>\n" + htmlStyle + h.css + "</style>\n")
+	b.WriteString("</head>\n<body>\n<table>\n")
+
+	// 表头为第一行
+	h.rowLine = 1
+	b.WriteString("<thead>\n" + h.formatRow("th", h.rowLine, h.header) + "</thead>\n<tbody>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeFooter 写入页面结尾和统计信息
+func (h *HTML) writeFooter(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("</tbody>\n</table>\n")
+	b.WriteString("<div class=\"summary\">\n")
+	b.WriteString("<pre>" + html.EscapeString(my.SQL()) + "</pre>\n")
+	b.WriteString("<span>Rows: " + strconv.Itoa(h.file.curLine) + "</span>\n")
+	b.WriteString("<span>Generated: " + time.Now().Format(time.DateTime) + "</span>\n")
+	b.WriteString("</div>\n</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatRow 生成一行,设置了行样式的行会添加class
+func (h *HTML) formatRow(tag string, rowIndex int, values []string) string {
+	var b strings.Builder
+	if h.hasRowStyle(rowIndex) {
+		b.WriteString("<tr class=\"r" + strconv.Itoa(rowIndex) + "\">")
+	} else {
+		b.WriteString("<tr>")
+	}
+	for i, value := range values {
+		b.WriteString("<" + tag + " class=\"c" + strconv.Itoa(i+1) + "\">")
+		b.WriteString(html.EscapeString(value))
+		b.WriteString("</" + tag + ">")
+	}
+	b.WriteString("</tr>\n")
+	return b.String()
+}
+
+func (h *HTML) hasRowStyle(rowIndex int) bool {
+	_, height := excel.rowHeightMap[rowIndex]
+	_, bgColor := excel.rowBgColorMap[rowIndex]
+	_, fontColor := excel.rowFontColorMap[rowIndex]
+	_, fontSize := excel.rowFontSizeMap[rowIndex]
+	return height || bgColor || fontColor || fontSize
+}
+
+// parseCSS 将样式flags转为CSS,与Excel一致,行样式优先于列样式
+func (h *HTML) parseCSS() string {
+	colStyles := make(map[int][]string)
+	for i, width := range excel.colWidthMap {
+		colStyles[i] = append(colStyles[i], fmt.Sprintf("min-width: %gch", width))
+	}
+	for i, align := range excel.colAlignMap {
+		colStyles[i] = append(colStyles[i], "text-align: "+align)
+	}
+	for i, color := range excel.colBgColorMap {
+		colStyles[i] = append(colStyles[i], "background: "+color)
+	}
+	for i, color := range excel.colFontColorMap {
+		colStyles[i] = append(colStyles[i], "color: "+color)
+	}
+	for i, size := range excel.colFontSizeMap {
+		colStyles[i] = append(colStyles[i], fmt.Sprintf("font-size: %gpt", size))
+	}
+
+	rowStyles := make(map[int][]string)
+	for i, height := range excel.rowHeightMap {
+		rowStyles[i] = append(rowStyles[i], fmt.Sprintf("height: %gpt", height))
+	}
+	for i, color := range excel.rowBgColorMap {
+		rowStyles[i] = append(rowStyles[i], "background: "+color)
+	}
+	for i, color := range excel.rowFontColorMap {
+		rowStyles[i] = append(rowStyles[i], "color: "+color)
+	}
+	for i, size := range excel.rowFontSizeMap {
+		rowStyles[i] = append(rowStyles[i], fmt.Sprintf("font-size: %gpt", size))
+	}
+
+	var b strings.Builder
+	for _, i := range sortedKeys(colStyles) {
+		b.WriteString(fmt.Sprintf(".c%d { %s; }\n", i, strings.Join(colStyles[i], "; ")))
+	}
+	for _, i := range sortedKeys(rowStyles) {
+		b.WriteString(fmt.Sprintf("tr.r%d > * { %s; }\n", i, strings.Join(rowStyles[i], "; ")))
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[int][]string) []int {
+	var keys []int
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
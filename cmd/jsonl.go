@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"math/big"
+)
+
+// JSONLines 每行数据输出为一个JSON对象,键为列名称,值尽量保留数据库中的类型
+type JSONLines struct {
+	// 与Excel共用的flags
+	output      string // 输出文件
+	maxFileLine int    // 每个文件最多允许写入多少行
+
+	keys  [][]byte          // JSON编码后的列名称
+	types []*sql.ColumnType // 列类型
+	file  *TextFile
+}
+
+func NewJSONLines() *JSONLines {
+	return &JSONLines{}
+}
+
+func (j *JSONLines) Init() error {
+	j.file = NewTextFile(j.output, j.maxFileLine)
+	return nil
+}
+
+func (j *JSONLines) SetColumns(names []string, types []*sql.ColumnType) error {
+	for _, name := range names {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		j.keys = append(j.keys, key)
+	}
+	j.types = types
+	return j.file.Open()
+}
+
+func (j *JSONLines) WriteRow(row []any) error {
+	w, err := j.file.Next()
+	if err != nil {
+		return err
+	}
+
+	text := my.ParseText(row)
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, v := range row {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(j.keys[i])
+		b.WriteByte(':')
+
+		value, err := j.parseValue(i, v, text[i])
+		if err != nil {
+			return err
+		}
+		b.Write(value)
+	}
+	b.WriteString("}\n")
+
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// WriteMarker 提示信息写为一个单独的JSON对象: {"_marker": "..."}
+func (j *JSONLines) WriteMarker(text string) error {
+	w, err := j.file.Writer()
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(map[string]string{"_marker": text})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(value, '\n'))
+	return err
+}
+
+func (j *JSONLines) Close() error {
+	return j.file.Close()
+}
+
+// parseValue 将一个字段转为JSON值
+func (j *JSONLines) parseValue(i int, v any, text string) ([]byte, error) {
+	// 空值
+	if v == nil {
+		return []byte("null"), nil
+	}
+
+	// 获取数据库类型
+	dTypeName := j.types[i].DatabaseTypeName()
+	value, isBytes := v.([]byte)
+
+	// 数字类型原样输出,保留DECIMAL的精度
+	if isBytes && in(dTypeName, []string{
+		"TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT",
+		"UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED INT", "UNSIGNED BIGINT",
+		"DECIMAL", "FLOAT", "DOUBLE", "YEAR",
+	}) && json.Valid(value) {
+		return value, nil
+	}
+
+	// BIT类型转为数字
+	if isBytes && dTypeName == "BIT" {
+		return []byte(new(big.Int).SetBytes(value).String()), nil
+	}
+
+	// 二进制类型转为base64
+	if isBytes && in(dTypeName, []string{"BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "GEOMETRY"}) {
+		return json.Marshal(value)
+	}
+
+	// JSON类型直接嵌入
+	if isBytes && dTypeName == "JSON" && json.Valid(value) {
+		var b bytes.Buffer
+		err := json.Compact(&b, value)
+		return b.Bytes(), err
+	}
+
+	// 其他类型输出为字符串
+	return json.Marshal(text)
+}
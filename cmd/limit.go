@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	driver "github.com/go-sql-driver/mysql"
+
+	"github.com/vvfock3r/mysqlexport/kernel/module/mysql"
+)
+
+// outputSize 已经写入的字节数,用于--max-output-size
+// 文本格式为实际写入文件的字节数(压缩后),xlsx根据单元格的数据估算,ods为未压缩的表格数据大小
+var outputSize int64
+
+// Limit 导出的资源限制,超过限制时停止读取数据,并在输出中写入提示信息
+type Limit struct {
+	// flags
+	maxRows       int    // 每条SQL最多导出多少行,小于等于0代表不限制
+	maxOutputSize string // 最多写入多少字节,支持KB、MB、GB单位,为空代表不限制
+
+	maxOutputBytes int64 // maxOutputSize解析结果
+}
+
+func NewLimit() *Limit {
+	return &Limit{}
+}
+
+func (l *Limit) Init() error {
+	if l.maxOutputSize == "" {
+		return nil
+	}
+
+	size, err := parseSize(l.maxOutputSize)
+	if err != nil {
+		return fmt.Errorf("parse --max-output-size error: %w", err)
+	}
+	l.maxOutputBytes = size
+	return nil
+}
+
+// Check 检查是否超过限制,rows为当前SQL已经导出的行数,--resume时包含检查点之前导出的行数,返回超过限制的原因,没有超过时返回空字符串
+func (l *Limit) Check(rows int) string {
+	if l.maxRows > 0 && rows >= l.maxRows {
+		return fmt.Sprintf("the result is truncated, it exceeds the --max-rows %d", l.maxRows)
+	}
+	if l.OutputExceeded() {
+		return fmt.Sprintf("the result is truncated, the output exceeds the --max-output-size %s", l.maxOutputSize)
+	}
+	return ""
+}
+
+// OutputExceeded 是否超过了--max-output-size,超过后不再执行后面的SQL
+func (l *Limit) OutputExceeded() bool {
+	return l.maxOutputBytes > 0 && outputSize >= l.maxOutputBytes
+}
+
+// Wrap 一致性快照的专用连接不能断开,停止读取后关闭结果集时驱动会读取剩余的所有数据,
+// 所以指定了--max-rows时在SQL中限制行数,多查询一行用于判断是否超过限制,返回是否添加了限制
+// 分页查询时每页已经限制了行数;只有SELECT、WITH、TABLE语句可以作为派生表,SHOW等语句不做处理
+func (l *Limit) Wrap(query string) (string, bool) {
+	if l.maxRows <= 0 || mysql.Conn == nil || resume.Enabled() || !isSelect(query) {
+		return query, false
+	}
+	return "SELECT * FROM (" + query + ") AS mysqlexport_limit LIMIT " + strconv.Itoa(l.maxRows+1), true
+}
+
+// isDuplicateColumn 是否为派生表中存在重复的列名称的错误,比如 SELECT a.id, b.id
+func isDuplicateColumn(err error) bool {
+	var mysqlErr *driver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1060
+}
+
+// parseSize 解析大小,比如 512KB、100MB、1GB,没有单位时为字节
+func parseSize(size string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	unit := int64(1)
+	for suffix, n := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if strings.HasSuffix(value, suffix) {
+			value, unit = strings.TrimSuffix(value, suffix), n
+			break
+		}
+	}
+	value = strings.TrimSuffix(value, "B")
+
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size: %s, e.g. 512KB, 100MB, 1GB", size)
+	}
+	return n * unit, nil
+}
+
+// cellSize 估算单元格数据的大小
+func cellSize(v any) int64 {
+	switch value := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(value))
+	case []byte:
+		return int64(len(value))
+	default:
+		return int64(len(fmt.Sprint(value)))
+	}
+}
+
+// countWriter 统计写入的字节数
+type countWriter struct {
+	w io.Writer
+}
+
+func (c countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	outputSize += int64(n)
+	return n, err
+}
+
+// limitError 为超过--max-execution-time的错误添加说明
+func limitError(err error) error {
+	var mysqlErr *driver.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 3024 {
+		return fmt.Errorf("the query exceeds the --max-execution-time: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"database/sql"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// markdownEscaper 转义单元格中的竖线和换行符
+var markdownEscaper = strings.NewReplacer(
+	"|", "\\|",
+	"\r\n", "<br>",
+	"\n", "<br>",
+	"\r", "<br>",
+)
+
+// Markdown 输出为GitHub风格的Markdown表格
+type Markdown struct {
+	// flags
+	maxWidth int // 单元格最大宽度(字符数),超过会被截断,小于等于0代表不限制
+
+	// 与Excel共用的flags
+	output      string // 输出文件
+	maxFileLine int    // 每个文件最多允许写入多少行
+
+	header []string
+	file   *TextFile
+}
+
+func NewMarkdown() *Markdown {
+	return &Markdown{}
+}
+
+func (m *Markdown) Init() error {
+	// 对齐方式使用--col-align
+	err := excel.SetColAlign()
+	if err != nil {
+		return err
+	}
+
+	m.file = NewTextFile(m.output, m.maxFileLine)
+	m.file.onOpen = m.writeHeader
+
+	return nil
+}
+
+func (m *Markdown) SetColumns(names []string, _ []*sql.ColumnType) error {
+	m.header = names
+	return m.file.Open()
+}
+
+func (m *Markdown) WriteRow(row []any) error {
+	w, err := m.file.Next()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, m.formatRow(my.ParseText(row)))
+	return err
+}
+
+// WriteMarker 提示信息加粗写在表格的最后一行
+func (m *Markdown) WriteMarker(text string) error {
+	w, err := m.file.Writer()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "| **"+markdownEscaper.Replace(text)+"** |\n")
+	return err
+}
+
+func (m *Markdown) Close() error {
+	return m.file.Close()
+}
+
+// writeHeader 每个新文件都需要写入表头和分隔行
+func (m *Markdown) writeHeader(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(m.formatRow(m.header))
+
+	b.WriteString("|")
+	for i := range m.header {
+		switch excel.colAlignMap[i+1] {
+		case "left":
+			b.WriteString(" :--- |")
+		case "center":
+			b.WriteString(" :---: |")
+		case "right":
+			b.WriteString(" ---: |")
+		default:
+			b.WriteString(" --- |")
+		}
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatRow 生成一行,超过最大宽度的单元格会被截断
+func (m *Markdown) formatRow(values []string) string {
+	var b strings.Builder
+	b.WriteString("|")
+	for _, value := range values {
+		if m.maxWidth > 0 && utf8.RuneCountInString(value) > m.maxWidth {
+			value = string([]rune(value)[:m.maxWidth]) + "…"
+		}
+		b.WriteString(" " + markdownEscaper.Replace(value) + " |")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
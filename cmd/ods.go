@@ -0,0 +1,651 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bufio"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vvfock3r/mysqlexport/kernel/module/mysql"
+)
+
+const (
+	odsMimeType = "application/vnd.oasis.opendocument.spreadsheet"
+
+	odsManifestHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">
+ <manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="application/vnd.oasis.opendocument.spreadsheet"/>
+ <manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>
+`
+	odsManifestMeta = ` <manifest:file-entry manifest:full-path="meta.xml" manifest:media-type="text/xml"/>
+`
+	odsManifestFooter = `</manifest:manifest>
+`
+
+	odsMetaHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" xmlns:dc="http://purl.org/dc/elements/1.1/" office:version="1.2">
+<office:meta>
+`
+	odsMetaFooter = `</office:meta>
+</office:document-meta>
+`
+
+	odsContentHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" xmlns:number="urn:oasis:names:tc:opendocument:xmlns:datastyle:1.0" office:version="1.2">
+`
+
+	// 日期、日期时间和时长单元格的数据样式,与Excel默认的 yyyy-mm-dd、yyyy-mm-dd hh:mm:ss、[h]:mm:ss 一致
+	odsDateStyles = `<number:date-style style:name="N1"><number:year number:style="long"/><number:text>-</number:text><number:month number:style="long"/><number:text>-</number:text><number:day number:style="long"/></number:date-style>
+<number:date-style style:name="N2"><number:year number:style="long"/><number:text>-</number:text><number:month number:style="long"/><number:text>-</number:text><number:day number:style="long"/><number:text> </number:text><number:hours number:style="long"/><number:text>:</number:text><number:minutes number:style="long"/><number:text>:</number:text><number:seconds number:style="long"/></number:date-style>
+<number:time-style style:name="N3" number:truncate-on-overflow="false"><number:hours/><number:text>:</number:text><number:minutes number:style="long"/><number:text>:</number:text><number:seconds number:style="long"/></number:time-style>
+`
+
+	odsContentFooter = `</office:spreadsheet>
+</office:body>
+</office:document-content>
+`
+)
+
+// 单元格的数据样式
+const (
+	odsNoDataStyle   = iota // 没有数据样式
+	odsDateStyle            // 日期 N1
+	odsDateTimeStyle        // 日期时间 N2
+	odsTimeStyle            // 时长 N3
+)
+
+// ODS 输出为OpenDocument电子表格
+// 表格数据先流式写入临时文件,保存时再组装为content.xml,与excelize.StreamWriter的做法类似
+type ODS struct {
+	// 与Excel共用的flags
+	output          string // 输出文件
+	maxWorkbookLine int    // 每个Workbook最多允许写入多少行，不包含表头
+	maxSheetLine    int    // 每个Sheet最多允许写入多少行，不包含表头
+
+	header     []string          // 表头
+	colCount   int               // 最大列数,多条SQL时列数可能不同
+	styles     string            // 自动样式
+	columns    string            // 列定义
+	cellStyles map[[3]int]string // 单元格样式名称,键为 行号,列号,数据样式, 未设置行样式的行号为0
+	styledRows map[int]bool      // 设置了行样式的行号
+	styleNames map[string]string // 单元格样式的属性与名称的对应关系,列数增加时已有的样式名称保持不变
+	styleDefs  []string          // 单元格样式的定义,按照生成的顺序
+
+	// 临时文件
+	body       *os.File
+	bodyW      *bufio.Writer
+	bodySize   int64    // 临时文件已写入的字节数
+	sheetEnds  []int64  // 每个Sheet在临时文件中的结束位置
+	sheetNames []string // 每个Sheet的名称
+
+	curWorkbookLine    int    // 当前Workbook累计写入了多少行，不包含表头
+	curSheetLine       int    // 当前Sheet写入了多少行，不包含表头
+	curSheetHeaderLine int    // 当前Sheet写入了多少行，包含表头
+	curlTotalLine      int    // 当前总共写入了多少行，不包含表头
+	curSheetName       string // 当前SQL对应的工作表名称
+	curSheetIndex      int    // 当前SQL在当前Workbook中的第几个工作表
+}
+
+func NewODS() *ODS {
+	return &ODS{
+		cellStyles: make(map[[3]int]string),
+		styledRows: make(map[int]bool),
+		styleNames: make(map[string]string),
+	}
+}
+
+func (o *ODS) Init() error {
+	return excel.ParseStyle()
+}
+
+// SetSheetName 设置下一次SetColumns新建的工作表名称,多条SQL时每条SQL调用一次
+func (o *ODS) SetSheetName(name string) {
+	o.curSheetName = name
+	o.curSheetIndex = 0
+}
+
+func (o *ODS) SetColumns(names []string, _ []*sql.ColumnType) error {
+	names = my.cellNames(names)
+	o.header = names
+	if len(names) > o.colCount {
+		o.colCount = len(names)
+		o.parseStyle(o.colCount)
+	}
+
+	if o.body == nil {
+		err := o.newWorkbook()
+		if err != nil {
+			return err
+		}
+	} else {
+		// 多条SQL时每条SQL写入一个新的工作表
+		err := o.newSheet()
+		if err != nil {
+			return err
+		}
+	}
+
+	// 没有数据时也保留表头
+	return o.writeHeader()
+}
+
+func (o *ODS) WriteRow(row []any) error {
+	values, err := my.ParseRow(row)
+	if err != nil {
+		return err
+	}
+	var cells []any
+	for _, value := range values {
+		cells = append(cells, value.Value)
+	}
+	return o.addRow(cells)
+}
+
+// WriteMarker 提示信息写在当前工作表的下一行的第一列,不计入行数,也不会拆分新的工作表或工作簿
+func (o *ODS) WriteMarker(text string) error {
+	err := o.writeRow([]any{text})
+	if err != nil {
+		return err
+	}
+	o.curSheetHeaderLine++
+	return nil
+}
+
+// addRow 添加一行,超过最大行数时自动新建工作簿或工作表
+func (o *ODS) addRow(cells []any) error {
+	// 超过工作簿最大行数则重新建一个
+	if o.maxWorkbookLine > 0 && o.curWorkbookLine+1 > o.maxWorkbookLine {
+		err := o.Close()
+		if err != nil {
+			return err
+		}
+
+		err = o.newWorkbook()
+		if err != nil {
+			return err
+		}
+	}
+
+	// 超过工作表最大行数则重新建一个
+	if o.maxSheetLine > 0 && o.curSheetLine+1 > o.maxSheetLine {
+		err := o.newSheet()
+		if err != nil {
+			return err
+		}
+	}
+
+	// 第一行添加表头
+	if o.curSheetHeaderLine == 0 {
+		err := o.writeHeader()
+		if err != nil {
+			return err
+		}
+	}
+
+	// 写入数据
+	err := o.writeRow(cells)
+	if err != nil {
+		return err
+	}
+
+	// 计数加1
+	o.curSheetLine++
+	o.curSheetHeaderLine++
+	o.curWorkbookLine++
+	o.curlTotalLine++
+
+	return nil
+}
+
+// Close 组装并保存当前工作簿
+func (o *ODS) Close() error {
+	// 还没有写入任何数据
+	if o.body == nil {
+		return nil
+	}
+	o.sheetEnds = append(o.sheetEnds, o.bodySize)
+
+	err := o.bodyW.Flush()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = o.body.Close()
+		removeTemp(o.body.Name())
+	}()
+
+	output, err := partOutput(o.output, o.curlTotalLine, o.maxWorkbookLine)
+	if err != nil {
+		return err
+	}
+	// 输出到标准输出
+	file := os.Stdout
+	if !isStdout(o.output) {
+		file, err = createTemp(output)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+	}
+
+	zw := zip.NewWriter(file)
+
+	// mimetype必须是第一个文件,并且不能压缩
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, odsMimeType)
+	if err != nil {
+		return err
+	}
+
+	w, err = zw.Create("META-INF/manifest.xml")
+	if err != nil {
+		return err
+	}
+	manifest := odsManifestHeader
+	if mysql.Snapshot != nil {
+		manifest += odsManifestMeta
+	}
+	_, err = io.WriteString(w, manifest+odsManifestFooter)
+	if err != nil {
+		return err
+	}
+
+	// 一致性快照对应的binlog位置记录在文档属性中
+	if mysql.Snapshot != nil {
+		w, err = zw.Create("meta.xml")
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, odsMeta(mysql.Snapshot))
+		if err != nil {
+			return err
+		}
+	}
+
+	w, err = zw.Create("content.xml")
+	if err != nil {
+		return err
+	}
+	err = o.writeContent(w)
+	if err != nil {
+		return err
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return err
+	}
+
+	if isStdout(o.output) {
+		return nil
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	err = commitTemp(file.Name(), output)
+	if err != nil {
+		return err
+	}
+
+	// 记录生成的文件
+	return addOutputFile(output, o.curWorkbookLine)
+}
+
+// odsMeta 生成文档属性,备注中为一致性快照对应的binlog位置,每一项同时作为自定义属性
+func odsMeta(snapshot *mysql.BinlogPosition) string {
+	var b strings.Builder
+	b.WriteString(odsMetaHeader)
+	b.WriteString("<dc:description>" + xmlEscape("consistent snapshot: "+snapshot.String()) + "</dc:description>\n")
+	userDefined := func(name, value string) {
+		b.WriteString(`<meta:user-defined meta:name="` + name + `">` + xmlEscape(value) + "</meta:user-defined>\n")
+	}
+	if snapshot.File != "" {
+		userDefined("binlog_file", snapshot.File)
+		userDefined("binlog_position", strconv.FormatUint(snapshot.Position, 10))
+	}
+	if snapshot.GTIDExecuted != "" {
+		userDefined("gtid_executed", snapshot.GTIDExecuted)
+	}
+	userDefined("exact", strconv.FormatBool(snapshot.Exact))
+	b.WriteString(odsMetaFooter)
+	return b.String()
+}
+
+// newWorkbook 新建临时文件用于存储表格数据,与输出文件在同一个目录中,出错退出时同样会被删除
+func (o *ODS) newWorkbook() error {
+	output := o.output
+	if isStdout(output) {
+		output = filepath.Join(os.TempDir(), "mysqlexport.ods")
+	}
+	body, err := createTemp(output + ".body")
+	if err != nil {
+		return err
+	}
+	o.body = body
+	o.bodyW = bufio.NewWriter(body)
+	o.bodySize = 0
+	o.sheetEnds = nil
+	o.sheetNames = nil
+
+	o.curWorkbookLine = 0
+	o.curSheetIndex = 0
+
+	return o.newSheet()
+}
+
+// newSheet 结束当前工作表并开始一个新的工作表,命名规则与Excel一致
+func (o *ODS) newSheet() error {
+	if len(o.sheetNames) > 0 {
+		o.sheetEnds = append(o.sheetEnds, o.bodySize)
+	}
+	o.curSheetIndex++
+
+	var name string
+	switch {
+	case o.curSheetName == "":
+		name = "Sheet" + strconv.Itoa(len(o.sheetNames)+1)
+	case o.curSheetIndex == 1:
+		name = o.curSheetName
+	default:
+		name = o.curSheetName + "-" + strconv.Itoa(o.curSheetIndex)
+	}
+
+	// 同一条SQL有多个工作表时,第一个工作表也需要添加数字后缀
+	if o.curSheetName != "" && o.curSheetIndex == 2 {
+		o.sheetNames[len(o.sheetNames)-1] = o.curSheetName + "-1"
+	}
+
+	for _, v := range o.sheetNames {
+		if strings.EqualFold(v, name) {
+			return fmt.Errorf("duplicate sheet name: %s", name)
+		}
+	}
+	o.sheetNames = append(o.sheetNames, name)
+
+	o.curSheetLine = 0
+	o.curSheetHeaderLine = 0
+
+	return nil
+}
+
+// writeContent 写入content.xml,每个Sheet的数据从临时文件中复制
+func (o *ODS) writeContent(w io.Writer) error {
+	_, err := io.WriteString(w, odsContentHeader+o.styles+"<office:body>\n<office:spreadsheet>\n")
+	if err != nil {
+		return err
+	}
+
+	_, err = o.body.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	var start int64
+	for i, end := range o.sheetEnds {
+		_, err = io.WriteString(w, `<table:table table:name="`+xmlEscape(o.sheetNames[i])+`">`+"\n"+o.columns)
+		if err != nil {
+			return err
+		}
+		_, err = io.CopyN(w, o.body, end-start)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "</table:table>\n")
+		if err != nil {
+			return err
+		}
+		start = end
+	}
+
+	_, err = io.WriteString(w, odsContentFooter)
+	return err
+}
+
+func (o *ODS) writeHeader() error {
+	if len(o.header) == 0 {
+		return nil
+	}
+
+	var cells []any
+	for _, name := range o.header {
+		cells = append(cells, name)
+	}
+	err := o.writeRow(cells)
+	if err != nil {
+		return err
+	}
+	o.curSheetHeaderLine++
+	return nil
+}
+
+// writeRow 写入一行到临时文件
+func (o *ODS) writeRow(cells []any) error {
+	rowIndex := o.curSheetHeaderLine + 1
+
+	var b strings.Builder
+	if _, ok := excel.rowHeightMap[rowIndex]; ok {
+		b.WriteString(`<table:table-row table:style-name="ro` + strconv.Itoa(rowIndex) + `">`)
+	} else {
+		b.WriteString("<table:table-row>")
+	}
+
+	styleRow := 0
+	if o.styledRows[rowIndex] {
+		styleRow = rowIndex
+	}
+	for i, value := range cells {
+		// 日期时间和时长单元格使用对应的数据样式
+		dataStyle, scale := odsNoDataStyle, 0
+		switch value.(type) {
+		case time.Time:
+			col := my.cellColumn(i)
+			dataStyle = odsDateTimeStyle
+			if my.columnKinds[col] == kindDate {
+				dataStyle = odsDateStyle
+			}
+			scale = timeScale(my.columnTypes[col])
+		case time.Duration:
+			dataStyle = odsTimeStyle
+			scale = timeScale(my.columnTypes[my.cellColumn(i)])
+		}
+
+		b.WriteString("<table:table-cell")
+		if name, ok := o.cellStyles[[3]int{styleRow, i + 1, dataStyle}]; ok {
+			b.WriteString(` table:style-name="` + name + `"`)
+		}
+		b.WriteString(o.formatCell(value, dataStyle == odsDateStyle, scale))
+	}
+	b.WriteString("</table:table-row>\n")
+
+	n, err := o.bodyW.WriteString(b.String())
+	o.bodySize += int64(n)
+	outputSize += int64(n)
+	return err
+}
+
+// formatCell 生成单元格的类型、值和内容,dateOnly为true时日期单元格的内容不包含时间,scale为小数秒的位数
+func (o *ODS) formatCell(value any, dateOnly bool, scale int) string {
+	var valueType, valueAttr, text string
+	switch v := value.(type) {
+	case nil:
+		return "/>"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		text = fmt.Sprint(v)
+		valueType, valueAttr = "float", ` office:value="`+text+`"`
+	case float32:
+		text = strconv.FormatFloat(float64(v), 'f', -1, 32)
+		valueType, valueAttr = "float", ` office:value="`+text+`"`
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+		valueType, valueAttr = "float", ` office:value="`+text+`"`
+	case bool:
+		text = strconv.FormatBool(v)
+		valueType, valueAttr = "boolean", ` office:boolean-value="`+text+`"`
+	case time.Time:
+		text = dateText(v, dateOnly, scale)
+		valueType, valueAttr = "date", ` office:date-value="`+v.Format("2006-01-02T15:04:05.999999999")+`"`
+	case time.Duration:
+		text = clockText(v, scale)
+		valueType, valueAttr = "time", ` office:time-value="`+isoDuration(v, scale)+`"`
+	default:
+		text = fmt.Sprint(v)
+		valueType = "string"
+	}
+
+	var b strings.Builder
+	b.WriteString(` office:value-type="` + valueType + `"` + valueAttr + ">")
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString("<text:p>" + xmlEscape(line) + "</text:p>")
+	}
+	b.WriteString("</table:table-cell>")
+	return b.String()
+}
+
+// parseStyle 根据样式flags生成列、行和单元格的自动样式,与Excel一致,行样式优先于列样式
+// 多条SQL时列数增加会重新生成,之前的工作表已经写入临时文件,所以已有的单元格样式名称不能改变
+func (o *ODS) parseStyle(colCount int) {
+	var styles strings.Builder
+	styles.WriteString("<office:automatic-styles>\n")
+
+	// 列宽,Excel的列宽单位为字符宽度
+	var columns strings.Builder
+	for i := 1; i <= colCount; i++ {
+		width, ok := excel.colWidthMap[i]
+		if !ok {
+			columns.WriteString("<table:table-column/>\n")
+			continue
+		}
+		name := "co" + strconv.Itoa(i)
+		styles.WriteString(fmt.Sprintf(`<style:style style:name="%s" style:family="table-column"><style:table-column-properties style:column-width="%.3fin"/></style:style>`+"\n", name, (width*7+5)/96))
+		columns.WriteString(`<table:table-column table:style-name="` + name + `"/>` + "\n")
+	}
+	o.columns = columns.String()
+
+	// 行高
+	for _, i := range sortedFloatKeys(excel.rowHeightMap) {
+		styles.WriteString(fmt.Sprintf(`<style:style style:name="ro%d" style:family="table-row"><style:table-row-properties style:row-height="%gpt" style:use-optimal-row-height="false"/></style:style>`+"\n", i, excel.rowHeightMap[i]))
+	}
+
+	// 单元格样式,相同的样式只生成一次
+	for i := range excel.rowBgColorMap {
+		o.styledRows[i] = true
+	}
+	for i := range excel.rowFontColorMap {
+		o.styledRows[i] = true
+	}
+	for i := range excel.rowFontSizeMap {
+		o.styledRows[i] = true
+	}
+	rows := []int{0}
+	for i := range o.styledRows {
+		rows = append(rows, i)
+	}
+	sort.Ints(rows)
+
+	// 每个单元格样式还需要分别生成日期和日期时间的版本
+	styles.WriteString(odsDateStyles)
+	dataStyleAttrs := []string{
+		odsNoDataStyle:   "",
+		odsDateStyle:     ` style:data-style-name="N1"`,
+		odsDateTimeStyle: ` style:data-style-name="N2"`,
+		odsTimeStyle:     ` style:data-style-name="N3"`,
+	}
+
+	for _, row := range rows {
+		for col := 1; col <= colCount; col++ {
+			props := o.cellProperties(row, col)
+			for dataStyle, attr := range dataStyleAttrs {
+				key := attr + props
+				name, ok := o.styleNames[key]
+				if !ok {
+					name = "ce" + strconv.Itoa(len(o.styleNames)+1)
+					o.styleNames[key] = name
+					o.styleDefs = append(o.styleDefs, `<style:style style:name="`+name+`" style:family="table-cell"`+attr+">"+props+"</style:style>\n")
+				}
+				o.cellStyles[[3]int{row, col, dataStyle}] = name
+			}
+		}
+	}
+	for _, def := range o.styleDefs {
+		styles.WriteString(def)
+	}
+
+	styles.WriteString("</office:automatic-styles>\n")
+	o.styles = styles.String()
+}
+
+// cellProperties 生成单元格样式的属性
+func (o *ODS) cellProperties(rowIndex, colIndex int) string {
+	// 背景颜色
+	bgColor, ok := excel.rowBgColorMap[rowIndex]
+	if !ok {
+		bgColor = excel.colBgColorMap[colIndex]
+	}
+	cell := `<style:table-cell-properties style:vertical-align="middle"`
+	if bgColor != "" {
+		cell += ` fo:background-color="` + xmlEscape(bgColor) + `"`
+	}
+	cell += "/>"
+
+	// 对齐方式
+	var paragraph string
+	switch excel.colAlignMap[colIndex] {
+	case "center":
+		paragraph = `<style:paragraph-properties fo:text-align="center"/>`
+	case "right":
+		paragraph = `<style:paragraph-properties fo:text-align="end"/>`
+	case "justify":
+		paragraph = `<style:paragraph-properties fo:text-align="justify"/>`
+	}
+
+	// 字体颜色和大小
+	fontColor, ok := excel.rowFontColorMap[rowIndex]
+	if !ok {
+		fontColor = excel.colFontColorMap[colIndex]
+	}
+	fontSize, ok := excel.rowFontSizeMap[rowIndex]
+	if !ok {
+		fontSize = excel.colFontSizeMap[colIndex]
+	}
+	var text string
+	if fontColor != "" || fontSize > 0 {
+		text = "<style:text-properties"
+		if fontColor != "" {
+			text += ` fo:color="` + xmlEscape(fontColor) + `"`
+		}
+		if fontSize > 0 {
+			text += fmt.Sprintf(` fo:font-size="%gpt"`, fontSize)
+		}
+		text += "/>"
+	}
+
+	return cell + paragraph + text
+}
+
+func sortedFloatKeys(m map[int]float64) []int {
+	var keys []int
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// xmlEscape 转义XML文本和属性值
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
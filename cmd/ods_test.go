@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 多条SQL的列数不同时,之前的工作表已经写入临时文件,单元格样式的名称不能改变
+func TestODSStyleNamesStable(t *testing.T) {
+	saved := excel
+	t.Cleanup(func() { excel = saved })
+	excel = NewExcel()
+	excel.rowBgColorMap[1] = "#CCCCCC"
+	excel.colBgColorMap[1] = "#FF0000"
+	excel.colBgColorMap[3] = "#00FF00"
+
+	o := NewODS()
+	o.output = filepath.Join(t.TempDir(), "test.ods")
+	t.Cleanup(func() {
+		if o.body != nil {
+			_ = o.body.Close()
+		}
+	})
+
+	err := o.SetColumns([]string{"a"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := o.cellStyles[[3]int{1, 1, odsNoDataStyle}]
+	data := o.cellStyles[[3]int{0, 1, odsNoDataStyle}]
+
+	err = o.SetColumns([]string{"a", "b", "c"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := o.cellStyles[[3]int{1, 1, odsNoDataStyle}]; got != header {
+		t.Errorf("header style name changed from %s to %s", header, got)
+	}
+	if got := o.cellStyles[[3]int{0, 1, odsNoDataStyle}]; got != data {
+		t.Errorf("data style name changed from %s to %s", data, got)
+	}
+
+	for name, color := range map[string]string{header: "#CCCCCC", data: "#FF0000", o.cellStyles[[3]int{0, 3, odsNoDataStyle}]: "#00FF00"} {
+		def := `<style:style style:name="` + name + `" style:family="table-cell"><style:table-cell-properties style:vertical-align="middle" fo:background-color="` + color + `"/>`
+		if !strings.Contains(o.styles, def) {
+			t.Errorf("style %s with background %s is not found in the automatic styles", name, color)
+		}
+	}
+}
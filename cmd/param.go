@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/vvfock3r/mysqlexport/kernel/module/mysql"
+)
+
+// variableRegexp 匹配 {{name}} 形式的内置变量
+var variableRegexp = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// Param --param 指定的参数
+type Param struct {
+	Name  string
+	Value any
+}
+
+// parseParams 解析 name=value 形式的参数
+// 值中可以使用内置变量,比如 {{today}};值可以添加类型前缀: int:、float:、date:、str:,未指定时为字符串
+func parseParams(list []string, now time.Time) ([]Param, error) {
+	var params []Param
+	exists := make(map[string]bool)
+	for _, item := range list {
+		name, value, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("parse param error: %s, the format should be name=value", item)
+		}
+		if exists[name] {
+			return nil, fmt.Errorf("duplicate param: %s", name)
+		}
+		exists[name] = true
+
+		value, err := expandVariables(value, now)
+		if err != nil {
+			return nil, fmt.Errorf("parse param %s error: %w", name, err)
+		}
+
+		v, err := parseParamValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("parse param %s error: %w", name, err)
+		}
+		params = append(params, Param{Name: name, Value: v})
+	}
+	return params, nil
+}
+
+// parseParamValue 根据类型前缀转换参数值
+func parseParamValue(value string) (any, error) {
+	kind, v, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, nil
+	}
+
+	switch kind {
+	case "int":
+		return strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	case "float":
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	case "date":
+		v = strings.TrimSpace(v)
+		for _, layout := range []string{time.DateOnly, time.DateTime} {
+			t, err := time.ParseInLocation(layout, v, mysql.Location)
+			if err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid date: %s, supported formats: %s, %s", v, time.DateOnly, time.DateTime)
+	case "str":
+		return v, nil
+	default:
+		// 值本身包含冒号,比如 10:30
+		return value, nil
+	}
+}
+
+// builtinVariables 内置变量
+func builtinVariables(now time.Time) map[string]string {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return map[string]string{
+		"now":              now.Format(time.DateTime),
+		"today":            today.Format(time.DateOnly),
+		"yesterday":        today.AddDate(0, 0, -1).Format(time.DateOnly),
+		"tomorrow":         today.AddDate(0, 0, 1).Format(time.DateOnly),
+		"month_start":      monthStart.Format(time.DateOnly),
+		"last_month_start": monthStart.AddDate(0, -1, 0).Format(time.DateOnly),
+	}
+}
+
+// expandVariables 替换值中的内置变量
+func expandVariables(value string, now time.Time) (string, error) {
+	variables := builtinVariables(now)
+
+	var err error
+	value = variableRegexp.ReplaceAllStringFunc(value, func(s string) string {
+		name := variableRegexp.FindStringSubmatch(s)[1]
+		v, ok := variables[name]
+		if !ok && err == nil {
+			err = fmt.Errorf("unknown variable: %s", s)
+		}
+		return v
+	})
+	return value, err
+}
+
+// bindParams 将参数绑定到SQL的占位符中,返回的SQL只包含?占位符,参数由驱动发送给MySQL,不会拼接到SQL中
+// 支持 :name 和 ? 两种占位符,? 按照--param的顺序绑定
+func bindParams(query string, params []Param) (string, []any, error) {
+	if len(params) == 0 {
+		return query, nil, nil
+	}
+	count := countPlaceholders(query)
+
+	// :name
+	arg := make(map[string]any)
+	for _, p := range params {
+		arg[p.Name] = p.Value
+	}
+	bound, args, err := sqlx.Named(escapeQuotedColons(query), arg)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(args) > 0 {
+		if count > 0 {
+			return "", nil, fmt.Errorf("the ? and :name placeholders cannot be used in the same sql statement")
+		}
+		return bound, args, nil
+	}
+
+	// ?
+	if count == 0 {
+		return query, nil, nil
+	}
+	if count != len(params) {
+		return "", nil, fmt.Errorf("the sql statement has %d ? placeholders, but %d params are specified", count, len(params))
+	}
+	for _, p := range params {
+		args = append(args, p.Value)
+	}
+	return query, args, nil
+}
+
+// countPlaceholders 统计引号之外的?占位符数量
+func countPlaceholders(query string) int {
+	var count int
+	for i := 0; i < len(query); {
+		switch query[i] {
+		case '\'', '"', '`':
+			i = scanQuoted(query, i)
+		case '?':
+			count++
+			i++
+		default:
+			i++
+		}
+	}
+	return count
+}
+
+// escapeQuotedColons 将引号中的冒号转义为::,避免被sqlx当作参数,比如 '10:30'
+func escapeQuotedColons(query string) string {
+	var b strings.Builder
+	for i := 0; i < len(query); {
+		switch query[i] {
+		case '\'', '"', '`':
+			end := scanQuoted(query, i)
+			b.WriteString(strings.ReplaceAll(query[i:end], ":", "::"))
+			i = end
+		default:
+			b.WriteByte(query[i])
+			i++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Parquet 根据MySQL列类型生成Schema,按行组流式写入
+type Parquet struct {
+	// flags
+	compression  string // 压缩算法
+	rowGroupSize string // 行组大小,必须指定MB单位
+
+	// 与Excel共用的flags
+	output      string // 输出文件
+	maxFileLine int    // 每个文件最多允许写入多少行
+
+	// 存储flags解析结果
+	codec          parquet.CompressionCodec
+	rowGroupSizeMB int
+
+	schema []string          // Schema定义,每列一个
+	types  []*sql.ColumnType // 列类型
+	pw     *writer.CSVWriter
+	file   *TextFile
+}
+
+func NewParquet() *Parquet {
+	return &Parquet{}
+}
+
+func (p *Parquet) Init() error {
+	// 压缩算法
+	switch strings.ToLower(p.compression) {
+	case "none":
+		p.codec = parquet.CompressionCodec_UNCOMPRESSED
+	case "snappy":
+		p.codec = parquet.CompressionCodec_SNAPPY
+	case "gzip":
+		p.codec = parquet.CompressionCodec_GZIP
+	case "zstd":
+		p.codec = parquet.CompressionCodec_ZSTD
+	default:
+		return fmt.Errorf("unrecognized parquet compression: %s, supported values: none,snappy,gzip,zstd", p.compression)
+	}
+
+	// 行组大小
+	size := strings.ToLower(p.rowGroupSize)
+	if !strings.HasSuffix(size, "mb") {
+		return fmt.Errorf("the parquet row group size must specify the mb unit")
+	}
+	sizeMB, err := strconv.Atoi(strings.TrimSuffix(size, "mb"))
+	if err != nil || sizeMB <= 0 {
+		return fmt.Errorf("the parquet row group size type conversion failed")
+	}
+	p.rowGroupSizeMB = sizeMB
+
+	p.file = NewTextFile(p.output, p.maxFileLine)
+	p.file.onOpen = p.newWriter
+	p.file.onClose = p.stopWriter
+
+	return nil
+}
+
+func (p *Parquet) SetColumns(names []string, types []*sql.ColumnType) error {
+	// 列名称中不能包含Schema定义使用的特殊字符,并且不能重复(不区分大小写)
+	exists := make(map[string]bool)
+	for i, name := range names {
+		name = strings.NewReplacer(",", "_", "=", "_").Replace(name)
+		for n := 2; exists[strings.ToLower(name)]; n++ {
+			name = names[i] + "_" + strconv.Itoa(n)
+		}
+		exists[strings.ToLower(name)] = true
+
+		p.schema = append(p.schema, "name="+name+", "+p.parseType(types[i]))
+	}
+	p.types = types
+
+	return p.file.Open()
+}
+
+func (p *Parquet) WriteRow(row []any) error {
+	_, err := p.file.Next()
+	if err != nil {
+		return err
+	}
+
+	text := my.ParseText(row)
+	record := make([]any, len(row))
+	for i, v := range row {
+		record[i], err = p.parseValue(i, v, text[i])
+		if err != nil {
+			return fmt.Errorf("parquet column %s: %w", p.types[i].Name(), err)
+		}
+	}
+
+	return p.pw.Write(record)
+}
+
+// WriteMarker 提示信息写入文件的元数据中,键为mysqlexport.marker,不影响数据
+func (p *Parquet) WriteMarker(text string) error {
+	err := p.file.Open()
+	if err != nil {
+		return err
+	}
+	p.pw.Footer.KeyValueMetadata = append(p.pw.Footer.KeyValueMetadata, &parquet.KeyValue{Key: "mysqlexport.marker", Value: &text})
+	return nil
+}
+
+func (p *Parquet) Close() error {
+	return p.file.Close()
+}
+
+// newWriter 每个新文件都需要新建一个Parquet写入器
+func (p *Parquet) newWriter(w io.Writer) (err error) {
+	p.pw, err = writer.NewCSVWriterFromWriter(p.schema, w, 4)
+	if err != nil {
+		return err
+	}
+	p.pw.CompressionType = p.codec
+	p.pw.RowGroupSize = int64(p.rowGroupSizeMB) << 20 // N MiB
+	return nil
+}
+
+// stopWriter 写入剩余的行组和文件尾
+func (p *Parquet) stopWriter(io.Writer) error {
+	return p.pw.WriteStop()
+}
+
+// parseType 根据MySQL列类型生成Schema中的类型定义
+func (p *Parquet) parseType(columnType *sql.ColumnType) string {
+	var def string
+	switch dTypeName := columnType.DatabaseTypeName(); dTypeName {
+	// 数字类型
+	case "TINYINT":
+		def = "type=INT32, convertedtype=INT_8"
+	case "SMALLINT":
+		def = "type=INT32, convertedtype=INT_16"
+	case "MEDIUMINT", "INT", "YEAR":
+		def = "type=INT32, convertedtype=INT_32"
+	case "BIGINT":
+		def = "type=INT64, convertedtype=INT_64"
+	case "UNSIGNED TINYINT":
+		def = "type=INT32, convertedtype=UINT_8"
+	case "UNSIGNED SMALLINT":
+		def = "type=INT32, convertedtype=UINT_16"
+	case "UNSIGNED INT":
+		def = "type=INT32, convertedtype=UINT_32"
+	case "UNSIGNED BIGINT", "BIT":
+		def = "type=INT64, convertedtype=UINT_64"
+	case "FLOAT":
+		def = "type=FLOAT"
+	case "DOUBLE":
+		def = "type=DOUBLE"
+	case "DECIMAL":
+		precision, scale := decimalSize(columnType)
+		def = fmt.Sprintf("type=BYTE_ARRAY, convertedtype=DECIMAL, precision=%d, scale=%d", precision, scale)
+
+	// 时间类型
+	case "DATE":
+		def = "type=INT32, convertedtype=DATE"
+	case "DATETIME", "TIMESTAMP":
+		def = "type=INT64, convertedtype=TIMESTAMP_MICROS"
+
+	// 二进制类型
+	case "BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "GEOMETRY":
+		def = "type=BYTE_ARRAY"
+
+	// 其他类型均作为字符串
+	default:
+		def = "type=BYTE_ARRAY, convertedtype=UTF8"
+	}
+
+	// 可空
+	nullable, ok := columnType.Nullable()
+	if nullable || !ok {
+		return def + ", repetitiontype=OPTIONAL"
+	}
+	return def + ", repetitiontype=REQUIRED"
+}
+
+// epochDays DATE转为1970-01-01以来的天数,按照日期计算,与时区无关
+func epochDays(t time.Time) int32 {
+	y, m, d := t.Date()
+	return int32(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// parseValue 将一个字段转为Schema对应的Go类型
+func (p *Parquet) parseValue(i int, v any, text string) (any, error) {
+	// 空值
+	if v == nil {
+		return nil, nil
+	}
+
+	// 时间类型
+	if t, ok := v.(time.Time); ok {
+		if p.types[i].DatabaseTypeName() == "DATE" {
+			return epochDays(t), nil
+		}
+		return t.UnixMicro(), nil
+	}
+
+	switch dTypeName := p.types[i].DatabaseTypeName(); dTypeName {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "YEAR":
+		value, err := strconv.ParseInt(text, 10, 32)
+		return int32(value), err
+	case "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED INT":
+		value, err := strconv.ParseUint(text, 10, 32)
+		return int32(value), err
+	case "BIGINT":
+		return strconv.ParseInt(text, 10, 64)
+	case "UNSIGNED BIGINT":
+		value, err := strconv.ParseUint(text, 10, 64)
+		return int64(value), err
+	case "BIT":
+		return new(big.Int).SetBytes(v.([]byte)).Int64(), nil
+	case "FLOAT":
+		value, err := strconv.ParseFloat(text, 32)
+		return float32(value), err
+	case "DOUBLE":
+		return strconv.ParseFloat(text, 64)
+	case "DECIMAL":
+		_, scale, ok := p.types[i].DecimalSize()
+		if !ok {
+			scale = 30
+		}
+		return decimalBytes(text, int(scale))
+	case "BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "GEOMETRY":
+		return string(v.([]byte)), nil
+	default:
+		return text, nil
+	}
+}
+
+// decimalBytes 将十进制字符串转为Parquet DECIMAL使用的大端序二进制补码
+func decimalBytes(s string, scale int) (string, error) {
+	// 按scale补齐小数位后去掉小数点,得到未缩放的整数
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if len(fracPart) < scale {
+		fracPart += strings.Repeat("0", scale-len(fracPart))
+	}
+	n, ok := new(big.Int).SetString(intPart+fracPart[:scale], 10)
+	if !ok {
+		return "", fmt.Errorf("invalid decimal value: %s", s)
+	}
+
+	// 正数需要保证最高位为0
+	if n.Sign() >= 0 {
+		b := n.Bytes()
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+		return string(b), nil
+	}
+
+	// 负数: 2^(8*N) + n
+	size := (n.BitLen() + 8) / 8
+	m := new(big.Int).Lsh(big.NewInt(1), uint(size*8))
+	return string(m.Add(m, n).Bytes()), nil
+}
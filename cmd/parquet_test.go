@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEpochDays(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		date time.Time
+		want int32
+	}{
+		{time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), 0},
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, shanghai), 19723},
+		{time.Date(1960, 1, 1, 0, 0, 0, 0, newYork), -3653},
+		{time.Date(1960, 1, 1, 0, 0, 0, 0, shanghai), -3653},
+	}
+	for _, tt := range tests {
+		if got := epochDays(tt.date); got != tt.want {
+			t.Errorf("epochDays(%v) = %d, want %d", tt.date, got, tt.want)
+		}
+	}
+}
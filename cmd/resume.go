@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vvfock3r/mysqlexport/kernel/module/logger"
+	"github.com/vvfock3r/mysqlexport/kernel/module/mysql"
+)
+
+// Checkpoint 断点续传的检查点,每个文件写满并保存后更新
+type Checkpoint struct {
+	SQL          string           `json:"sql"`
+	Params       []string         `json:"params,omitempty"`
+	Format       string           `json:"format"`
+	WorkbookLine int              `json:"workbook_line"`
+	Key          string           `json:"key"`      // --resume-key
+	KeyKind      string           `json:"key_kind"` // 键的类型: int、uint、string、date、datetime
+	LastKey      string           `json:"last_key"` // 已经保存的最后一行的键
+	Rows         int              `json:"rows"`     // 已经保存的总行数
+	Files        []CheckpointFile `json:"files"`
+	UpdatedAt    string           `json:"updated_at"`
+}
+
+// CheckpointFile 检查点中记录的已经保存的文件
+type CheckpointFile struct {
+	Path string `json:"path"`
+	Rows int    `json:"rows"`
+
+	// 一致性快照对应的binlog位置,继续导出时会开启新的快照,所以每个文件单独记录
+	Snapshot *mysql.BinlogPosition `json:"snapshot,omitempty"`
+}
+
+// Resume 按照有序的唯一键分页查询(keyset pagination),并支持从检查点继续导出
+// 每页查询 SELECT * FROM (SQL) WHERE key > 上一页最后的键 ORDER BY key LIMIT batch-size,
+// 拆分为多个文件时每个文件写满并保存后写入检查点,--resume时从检查点继续,文件编号与一次导出完成时一致
+type Resume struct {
+	// flags
+	key    string // 分页使用的列名称,必须是有序、唯一且不为NULL的列
+	resume bool   // 是否从检查点继续导出
+
+	path       string      // 检查点文件
+	checkpoint *Checkpoint // 检查点,每个文件写满后更新
+	resumed    int         // 之前导出的行数,用于计算文件编号
+
+	keyIndex int    // 键在结果中的位置
+	keyKind  string // 键的类型
+	lastKey  *string
+	partRows int // 当前文件写入了多少行
+}
+
+func NewResume() *Resume {
+	return &Resume{keyIndex: -1}
+}
+
+func (r *Resume) Init(format string) error {
+	if r.key == "" {
+		if r.resume {
+			return fmt.Errorf("the --resume must be used with --resume-key")
+		}
+		return nil
+	}
+	if len(my.statements) > 1 {
+		return fmt.Errorf("the --resume-key cannot be used with multiple sql statements")
+	}
+	// 分页查询时SQL作为派生表
+	if !isSelect(my.statements[0].SQL) {
+		return fmt.Errorf("the --resume-key can only be used with SELECT, WITH and TABLE statements")
+	}
+	if isStdout(excel.output) {
+		return fmt.Errorf("the --resume-key cannot be used when writing to stdout")
+	}
+	if my.batchSize <= 0 {
+		return fmt.Errorf("the --batch-size must be greater than 0 when using --resume-key")
+	}
+	// 检查点在每个文件写满后更新,不拆分文件时不会记录检查点
+	if r.resume && excel.maxWorkbookLine <= 0 {
+		return fmt.Errorf("the --resume must be used with --workbook-line, the checkpoint is only written after each completed file")
+	}
+
+	r.path = excel.output + ".checkpoint"
+	r.checkpoint = &Checkpoint{
+		SQL:          my.SQL(),
+		Format:       format,
+		WorkbookLine: excel.maxWorkbookLine,
+		Key:          r.key,
+	}
+	for _, p := range my.params {
+		r.checkpoint.Params = append(r.checkpoint.Params, fmt.Sprintf("%s=%v", p.Name, p.Value))
+	}
+	if !r.resume {
+		return nil
+	}
+
+	// 读取检查点
+	data, err := os.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		logger.Warn("the checkpoint is not found, start from the beginning", zap.String("checkpoint", r.path))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var checkpoint Checkpoint
+	err = json.Unmarshal(data, &checkpoint)
+	if err != nil {
+		return fmt.Errorf("parse checkpoint %s error: %w", r.path, err)
+	}
+
+	// 只能继续同一个导出
+	switch {
+	case checkpoint.SQL != r.checkpoint.SQL:
+		return fmt.Errorf("the sql statement is different from the checkpoint %s", r.path)
+	case strings.Join(checkpoint.Params, "\n") != strings.Join(r.checkpoint.Params, "\n"):
+		return fmt.Errorf("the params are different from the checkpoint %s", r.path)
+	case checkpoint.Format != format, checkpoint.WorkbookLine != excel.maxWorkbookLine:
+		return fmt.Errorf("the --format and --workbook-line must be the same as the checkpoint %s", r.path)
+	case !strings.EqualFold(checkpoint.Key, r.key):
+		return fmt.Errorf("the --resume-key must be the same as the checkpoint %s", r.path)
+	}
+
+	r.checkpoint = &checkpoint
+	r.keyKind = checkpoint.KeyKind
+	r.lastKey = &checkpoint.LastKey
+	r.resumed = checkpoint.Rows
+
+	// 之前保存的文件同样需要打包
+	for _, f := range checkpoint.Files {
+		bundle.Add(f.Path, f.Rows)
+	}
+
+	logger.Info("resume from the checkpoint",
+		zap.String("checkpoint", r.path),
+		zap.String("last_key", checkpoint.LastKey),
+		zap.Int("rows", checkpoint.Rows),
+	)
+	return nil
+}
+
+// Overwritable 继续导出时会重新生成检查点之后的文件,比如中断时保存的不完整的文件,允许覆盖
+// 文件名称为 名称-N.扩展名,并且N大于检查点中的文件数量,其他文件仍然需要--overwrite
+func (r *Resume) Overwritable(path string) bool {
+	if r.resumed <= 0 {
+		return false
+	}
+	absOutput, err := filepath.Abs(trimCompressExt(excel.output))
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(trimCompressExt(path))
+	if err != nil {
+		return false
+	}
+
+	// 与partOutput的命名规则一致
+	dir, fileName := filepath.Split(absOutput)
+	outputList := strings.Split(fileName, ".")
+	prefix := dir + strings.Join(outputList[:len(outputList)-1], ".") + "-"
+	suffix := "." + outputList[len(outputList)-1]
+	if !strings.HasPrefix(absPath, prefix) || !strings.HasSuffix(absPath, suffix) || len(absPath) < len(prefix)+len(suffix) {
+		return false
+	}
+	index, err := strconv.Atoi(absPath[len(prefix) : len(absPath)-len(suffix)])
+	return err == nil && index > len(r.checkpoint.Files)
+}
+
+// Enabled 是否按照--resume-key分页查询
+func (r *Resume) Enabled() bool {
+	return r.key != ""
+}
+
+// Wrap 返回当前页的SQL和参数
+func (r *Resume) Wrap(query string, args []any) (string, []any, error) {
+	if !r.Enabled() {
+		return query, args, nil
+	}
+
+	key := "`" + strings.ReplaceAll(r.key, "`", "``") + "`"
+	query = "SELECT * FROM (" + query + ") AS mysqlexport_resume"
+	if r.lastKey != nil {
+		value, err := r.keyValue(*r.lastKey)
+		if err != nil {
+			return "", nil, err
+		}
+		query += " WHERE " + key + " > ?"
+		args = append(args[:len(args):len(args)], value)
+	}
+	query += " ORDER BY " + key + " LIMIT " + strconv.Itoa(my.batchSize)
+	return query, args, nil
+}
+
+// wrapError 分页查询时SQL作为派生表,结果中不能有重复的列名称
+func (r *Resume) wrapError(err error) error {
+	if r.Enabled() && isDuplicateColumn(err) {
+		return fmt.Errorf("the --resume-key requires unique column names in the result, use aliases for the duplicate columns: %w", err)
+	}
+	return err
+}
+
+// keyValue 按照键的类型转换,避免数字与字符串比较时转为浮点数导致精度丢失
+func (r *Resume) keyValue(key string) (any, error) {
+	switch r.keyKind {
+	case "int":
+		return strconv.ParseInt(key, 10, 64)
+	case "uint":
+		return strconv.ParseUint(key, 10, 64)
+	default:
+		return key, nil
+	}
+}
+
+// SetColumns 查找键在结果中的位置和类型
+func (r *Resume) SetColumns(names []string, types []*sql.ColumnType) error {
+	if !r.Enabled() {
+		return nil
+	}
+
+	r.keyIndex = -1
+	for i, name := range names {
+		if strings.EqualFold(name, r.key) {
+			r.keyIndex = i
+			break
+		}
+	}
+	if r.keyIndex < 0 {
+		return fmt.Errorf("the --resume-key %s is not found in the result columns", r.key)
+	}
+
+	switch columnKindOf(types[r.keyIndex]) {
+	case kindUint:
+		r.keyKind = "uint"
+	case kindInt:
+		r.keyKind = "int"
+	case kindDate:
+		r.keyKind = "date"
+	case kindDateTime:
+		r.keyKind = "datetime"
+	default:
+		r.keyKind = "string"
+	}
+	r.checkpoint.KeyKind = r.keyKind
+	return nil
+}
+
+// Track 记录已经写入的一行的键
+func (r *Resume) Track(row []any) error {
+	if !r.Enabled() {
+		return nil
+	}
+
+	var key string
+	switch v := row[r.keyIndex].(type) {
+	case nil:
+		return fmt.Errorf("the --resume-key %s must not be NULL", r.key)
+	case []byte:
+		key = string(v)
+	case time.Time:
+		// 与文本格式中的日期一致,保留全部小数秒,作为字符串与列比较
+		key = dateText(v, r.keyKind == "date", 6)
+	default:
+		key = fmt.Sprint(v)
+	}
+	r.lastKey = &key
+	r.partRows++
+	return nil
+}
+
+// Commit 每个文件保存后调用,文件写满时更新检查点,中断时保存的不完整的文件不会记录,继续导出时重新生成
+func (r *Resume) Commit(path string) error {
+	if !r.Enabled() {
+		return nil
+	}
+	rows := r.partRows
+	r.partRows = 0
+	if excel.maxWorkbookLine <= 0 || rows != excel.maxWorkbookLine {
+		return nil
+	}
+
+	r.checkpoint.LastKey = *r.lastKey
+	r.checkpoint.Rows += rows
+	r.checkpoint.Files = append(r.checkpoint.Files, CheckpointFile{Path: path, Rows: rows, Snapshot: mysql.Snapshot})
+	r.checkpoint.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	// 同样先写入临时文件再重命名,避免检查点只写入了一半
+	file, err := createTemp(r.path)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	err = encoder.Encode(r.checkpoint)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	return renameTemp(file.Name(), r.path)
+}
+
+// Done 导出完成后删除检查点
+func (r *Resume) Done() error {
+	if !r.Enabled() {
+		return nil
+	}
+	err := os.Remove(r.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vvfock3r/mysqlexport/kernel/module/logger"
+	"github.com/vvfock3r/mysqlexport/kernel/module/mysql"
+)
+
+// maxRetryBackoff 两次重试之间最长的等待时间
+const maxRetryBackoff = time.Minute
+
+// Retry 遇到连接断开、锁等待超时、死锁等临时错误时,等待一段时间后重新连接并重试
+type Retry struct {
+	// flags
+	maxRetries int    // 连续重试的最大次数,0代表不重试
+	backoff    string // 第一次重试之前等待的时间,之后每次翻倍
+
+	backoffDuration time.Duration // backoff解析结果
+	attempt         int           // 当前连续重试的次数
+}
+
+func NewRetry() *Retry {
+	return &Retry{}
+}
+
+func (r *Retry) Init() error {
+	backoff, err := time.ParseDuration(r.backoff)
+	if err != nil {
+		return fmt.Errorf("parse --retry-backoff error: %w", err)
+	}
+	if backoff < 0 {
+		return fmt.Errorf("--retry-backoff must not be negative: %s", r.backoff)
+	}
+	r.backoffDuration = backoff
+	return nil
+}
+
+// Reconnect 判断错误是否可以重试,可以重试时等待一段时间后重新连接MySQL,重新连接失败时继续重试
+// 返回nil代表已经重新连接,调用方可以重新执行查询;不能重试或者超过最大重试次数时返回错误
+func (r *Retry) Reconnect(ctx context.Context, err error) error {
+	for {
+		// 收到信号、一致性快照的专用连接断开后都无法重试
+		if ctx.Err() != nil || mysql.Conn != nil || !mysql.IsTransient(err) {
+			return err
+		}
+		if r.attempt >= r.maxRetries {
+			return fmt.Errorf("retried %d times: %w", r.attempt, err)
+		}
+		r.attempt++
+
+		// 指数退避,并在 [wait/2, wait] 之间随机抖动,避免多个任务同时重试
+		// 左移之前先和上限比较,避免溢出;backoff为0时不等待
+		wait := r.backoffDuration
+		if wait > maxRetryBackoff>>(r.attempt-1) {
+			wait = maxRetryBackoff
+		} else {
+			wait <<= r.attempt - 1
+		}
+		wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+		logger.Warn("transient mysql error, retry later",
+			zap.Error(err),
+			zap.Int("attempt", r.attempt),
+			zap.Int("max_retries", r.maxRetries),
+			zap.Duration("wait", wait),
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return context.Cause(ctx)
+		}
+
+		err = mysql.Reconnect(ctx)
+		if err == nil {
+			logger.Info("reconnect database success")
+			return nil
+		}
+	}
+}
+
+// Reset 成功读取数据后重置重试次数
+func (r *Retry) Reset() {
+	r.attempt = 0
+}
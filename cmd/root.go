@@ -1,11 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"math"
+	"io"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -21,20 +22,58 @@ import (
 )
 
 var (
-	my    = NewMySQL()
-	excel = NewExcel()
+	my      = NewMySQL()
+	excel   = NewExcel()
+	csv     = NewCSV()
+	jsonl   = NewJSONLines()
+	pq      = NewParquet()
+	dump    = NewSQLDump()
+	page    = NewHTML()
+	md      = NewMarkdown()
+	ods     = NewODS()
+	bundle  = NewBundle()
+	limit   = NewLimit()
+	explain = NewExplain()
+	resume  = NewResume()
+	retry   = NewRetry()
+
+	format   string // 输出格式
+	compress string // 压缩算法
 )
 
 type MySQL struct {
 	// flags
-	execute   string // 执行的SQL命令
-	batchSize int    // 数据库每遍历N次
-	delayTime string // 延迟多久
+	executeList []string // 执行的SQL命令,可以指定多次
+	sqlFile     string   // 从文件中读取SQL命令,可以包含多条SQL
+	paramList   []string // SQL参数,格式为 name=value
+	batchSize   int      // 数据库每遍历N次
+	delayTime   string   // 延迟多久
+
+	decimalPolicy string // DECIMAL写入电子表格的方式: auto、number、text
+	numericCols   string // 写入为数字的字符串列,列名称或者列序号,以逗号分隔
+	bitFormat     string // BIT(n)写入电子表格的方式: int、binary
+	setFormat     string // SET写入电子表格的方式: list、onehot
+	setDelimiter  string // --set-format list 时SET的分隔符
+	enumDropdown  bool   // 是否为ENUM列添加下拉列表
+
+	spreadsheet bool // 是否输出为电子表格,只有电子表格需要ENUM、SET、BIT列的定义
+
+	statements []Statement // 需要执行的所有SQL
+	params     []Param     // paramList解析结果
+	execute    string      // 当前执行的SQL
+	args       []any       // 当前执行的SQL绑定的参数
 
 	// 存储SQL查询结果
 	rows        *sqlx.Rows
-	columnNames []string          // 列名称
-	columnTypes []*sql.ColumnType // 列类型
+	cancel      context.CancelFunc // 取消当前的查询
+	columnNames []string           // 列名称
+	columnTypes []*sql.ColumnType  // 列类型
+	columnKinds []columnKind       // 列的类型分类
+	columnMetas []*columnMeta      // ENUM、SET、BIT列的定义,没有查询到时为nil
+	cellColumns []int              // 电子表格中每个单元格对应的列
+
+	// 每条SQL中的表的ENUM、SET、BIT列的定义
+	columnMetaCache map[string]*queryColumns
 
 	// 数据库每遍历N次延迟多久
 	delayDuration time.Duration // delayTime解析结果
@@ -53,148 +92,369 @@ func (m *MySQL) Close() error {
 	return mysql.DB.Close()
 }
 
-func (m *MySQL) Query() error {
+// ParseStatements 收集--execute和--sql-file中的所有SQL, - 代表从标准输入读取
+// 每个来源中都可以包含多条以分号分隔的SQL,注释和结尾的分号会被去掉
+func (m *MySQL) ParseStatements() error {
+	m.statements = nil
+	stdinRead := false
+
+	add := func(source, value string) error {
+		text := value
+		if value == "-" {
+			if stdinRead {
+				return fmt.Errorf("the stdin can only be read once, but %s also specifies -", source)
+			}
+			stdinRead = true
+
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("read sql from stdin error: %w", err)
+			}
+			text, source = string(data), "stdin"
+		}
+
+		list := splitStatements(text)
+		if len(list) == 0 {
+			return fmt.Errorf("no sql statement found in %s", source)
+		}
+		m.statements = append(m.statements, list...)
+		return nil
+	}
+
+	for _, v := range m.executeList {
+		err := add("--execute", v)
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.sqlFile != "" {
+		value := m.sqlFile
+		if value != "-" {
+			data, err := os.ReadFile(m.sqlFile)
+			if err != nil {
+				return err
+			}
+			value = string(data)
+		}
+		err := add("--sql-file "+m.sqlFile, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(m.statements) == 0 {
+		return fmt.Errorf("no sql statement to execute, use --execute or --sql-file")
+	}
+
+	// SQL语句必须是只读的,仅仅为了安全考虑,如果有特殊需求可以将下面的代码删除
+	for i, statement := range m.statements {
+		err := validateReadOnly(statement.SQL)
+		if err != nil {
+			if len(m.statements) > 1 {
+				return fmt.Errorf("sql statement %d: %w", i+1, err)
+			}
+			return err
+		}
+	}
+	m.execute = m.statements[0].SQL
+
+	// 解析参数
+	params, err := parseParams(m.paramList, time.Now().In(mysql.Location))
+	if err != nil {
+		return err
+	}
+	m.params = params
+
+	return nil
+}
+
+// SQL 返回所有SQL,多条SQL之间以分号分隔
+func (m *MySQL) SQL() string {
+	var list []string
+	for _, s := range m.statements {
+		list = append(list, s.SQL)
+	}
+	return strings.Join(list, ";\n")
+}
+
+func (m *MySQL) Query(ctx context.Context) error {
 	delayDuration, err := time.ParseDuration(m.delayTime)
 	if err != nil {
 		return err
 	}
 
-	// SQL语句必须以SELECT开头,仅仅为了安全考虑,如果有特殊需求可以将下面的代码删除
-	if !strings.HasPrefix(strings.ToUpper(m.execute), "SELECT") {
-		return fmt.Errorf("the sql statement must start with the select keyword")
+	// 绑定参数
+	query, args, err := bindParams(m.execute, m.params)
+	if err != nil {
+		return err
 	}
 
-	// 执行查询
-	rows, err := mysql.DB.Queryx(m.execute)
+	// 按照--resume-key分页
+	query, args, err = resume.Wrap(query, args)
 	if err != nil {
 		return err
 	}
 
+	// 一致性快照中按照--max-rows限制行数
+	unlimited := query
+	query, limited := limit.Wrap(query)
+
+	// 电子表格需要ENUM、SET、BIT列的定义
+	var columns *queryColumns
+	if m.spreadsheet {
+		columns = m.lookupColumnMetas(ctx)
+	}
+
+	// 执行查询
+	ctx, cancel := context.WithCancel(ctx)
+	rows, err := mysql.Queryer().QueryxContext(ctx, query, args...)
+	if err != nil && limited && isDuplicateColumn(err) {
+		// 结果中有重复的列名称时无法作为派生表,不限制行数,停止读取时仍然会读取剩余的数据
+		logger.Warn("the result has duplicate column names, cannot limit the rows in the sql for --max-rows", zap.Error(err))
+		rows, err = mysql.Queryer().QueryxContext(ctx, unlimited, args...)
+	}
+	if err != nil {
+		cancel()
+		return resume.wrapError(limitError(err))
+	}
+
 	// 获取列名称
 	columnNames, err := rows.Columns()
 	if err != nil {
+		_ = rows.Close()
+		cancel()
 		return err
 	}
 
 	// 获取列类型
 	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
+		_ = rows.Close()
+		cancel()
 		return err
 	}
 
 	m.delayDuration = delayDuration
+	m.cancel = cancel
+	m.args = args
 	m.rows = rows
 	m.columnNames = columnNames
 	m.columnTypes = columnTypes
+	m.columnKinds = nil
+	for _, columnType := range columnTypes {
+		m.columnKinds = append(m.columnKinds, columnKindOf(columnType))
+	}
+	m.resolveColumnMetas(columns)
+	for i, kind := range m.columnKinds {
+		if kind == kindString && m.isNumericCol(i, columnNames[i]) {
+			m.columnKinds[i] = kindNumeric
+		}
+	}
+	m.setCellColumns()
 
 	return nil
 }
 
+// CloseRows 关闭当前SQL的结果集,stop为true时代表数据没有读取完
+// 关闭结果集时驱动会读取剩余的所有数据,所以需要先取消查询直接断开连接;一致性快照的专用连接不能断开,只能读取剩余的数据,
+// 由于Limit.Wrap在SQL中限制了行数,超过--max-rows时不会再有剩余的数据
+func (m *MySQL) CloseRows(stop bool) {
+	if stop && mysql.Conn == nil {
+		m.cancel()
+	}
+	_ = m.rows.Close()
+	m.cancel()
+}
+
+// SliceScan 获取一行数据
+// 绑定参数时驱动使用二进制协议,返回的数字是int64、float64等类型,统一转为与文本协议一致的[]byte
+func (m *MySQL) SliceScan() ([]any, error) {
+	row, err := m.rows.SliceScan()
+	if err != nil || len(m.args) == 0 {
+		return row, err
+	}
+
+	for i, v := range row {
+		switch value := v.(type) {
+		case int64:
+			row[i] = []byte(strconv.FormatInt(value, 10))
+		case uint64:
+			row[i] = []byte(strconv.FormatUint(value, 10))
+		case float32:
+			row[i] = []byte(strconv.FormatFloat(float64(value), 'g', -1, 32))
+		case float64:
+			row[i] = []byte(strconv.FormatFloat(value, 'g', -1, 64))
+		case string:
+			row[i] = []byte(value)
+		}
+	}
+	return row, nil
+}
+
 func (m *MySQL) ParseRow(row []any) ([]excelize.Cell, error) {
 	var rowValue []excelize.Cell
 	for i, v := range row {
-		// 空值
+		// 空值,SET展开为多列时每列都为空
 		if v == nil {
 			rowValue = append(rowValue, excelize.Cell{Value: nil})
-			continue
-		}
-
-		// 获取数据库类型
-		dTypeName := m.columnTypes[i].DatabaseTypeName()
-
-		// 字符串类型
-		if in(dTypeName, []string{"CHAR", "VARCHAR", "TEXT"}) {
-			valueStr := string(v.([]byte))
-			valueInt, err := strconv.Atoi(valueStr)
-			if err == nil {
-				rowValue = append(rowValue, excelize.Cell{Value: valueInt})
-			} else {
-				rowValue = append(rowValue, excelize.Cell{Value: valueStr})
+			if m.oneHot(i) {
+				for range m.columnMetas[i].values[1:] {
+					rowValue = append(rowValue, excelize.Cell{Value: nil})
+				}
 			}
 			continue
 		}
-		if in(dTypeName, []string{"BINARY", "VARBINARY", "BLOB", "GEOMETRY"}) {
-			// 转为Go十六进制表示
-			value := fmt.Sprintf("0x%X\n", v)
 
-			// 收集对象
+		// 根据类型分类转换
+		switch m.columnKinds[i] {
+		// 字符串类型,保留为文本,避免邮编、手机号、身份证号等丢失开头的0或者精度
+		case kindString:
+			value := string(v.([]byte))
 			rowValue = append(rowValue, excelize.Cell{Value: value})
-			continue
-		}
-
-		// 数字类型
-		if in(dTypeName, []string{"TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT"}) {
-			// 转为Go数字类型
-			value, err := strconv.Atoi(string(v.([]byte)))
+		case kindNumeric:
+			value, err := numericCell(string(v.([]byte)), m.decimalPolicy)
 			if err != nil {
 				return nil, err
 			}
-			// 收集对象
 			rowValue = append(rowValue, excelize.Cell{Value: value})
-			continue
-		}
-		if in(dTypeName, []string{"DECIMAL", "FLOAT", "DOUBLE"}) {
-			// 转为Go数字类型
-			value, err := strconv.ParseFloat(string(v.([]byte)), 10)
+		case kindBinary:
+			// 转为十六进制表示
+			value := fmt.Sprintf("0x%X", v)
+			rowValue = append(rowValue, excelize.Cell{Value: value})
+		case kindBit:
+			bits := 0
+			if m.columnMetas[i] != nil {
+				bits = m.columnMetas[i].bits
+			}
+			value, err := bitCell(v.([]byte), bits, m.bitFormat, m.decimalPolicy)
 			if err != nil {
 				return nil, err
 			}
-			// 收集对象
 			rowValue = append(rowValue, excelize.Cell{Value: value})
-			continue
-		}
-		if in(dTypeName, []string{"BIT"}) {
-			value := fmt.Sprintf("0x%X\n", v)
+
+		// ENUM和SET
+		case kindEnum:
+			value := string(v.([]byte))
 			rowValue = append(rowValue, excelize.Cell{Value: value})
-			continue
-		}
+		case kindSet:
+			value := string(v.([]byte))
+			if !m.oneHot(i) {
+				rowValue = append(rowValue, excelize.Cell{Value: setCell(value, m.setDelimiter)})
+				continue
+			}
+			// 每个值一列,包含该值时为TRUE
+			members := strings.Split(value, ",")
+			for _, item := range m.columnMetas[i].values {
+				rowValue = append(rowValue, excelize.Cell{Value: in(item, members)})
+			}
 
-		// 时间类型
-		if in(dTypeName, []string{"DATE"}) {
-			value := v.(time.Time).Format(time.DateOnly)
+		// 数字类型,整数和DECIMAL都是能精确表示时才转为数字
+		case kindInt:
+			value, err := intCell(string(v.([]byte)), m.decimalPolicy)
+			if err != nil {
+				return nil, err
+			}
 			rowValue = append(rowValue, excelize.Cell{Value: value})
-			continue
-		}
-		if in(dTypeName, []string{"DATETIME", "TIMESTAMP"}) {
-			value := v.(time.Time).Format(time.DateTime)
+		case kindUint:
+			value, err := uintCell(string(v.([]byte)), m.decimalPolicy)
+			if err != nil {
+				return nil, err
+			}
 			rowValue = append(rowValue, excelize.Cell{Value: value})
-			continue
-		}
-		if in(dTypeName, []string{"TIME", "YEAR"}) {
-			valueStr := string(v.([]byte))
-			valueInt, err := strconv.Atoi(valueStr)
-			if err == nil {
-				rowValue = append(rowValue, excelize.Cell{Value: valueInt})
+		case kindDecimal:
+			value, err := decimalCell(string(v.([]byte)), m.decimalPolicy)
+			if err != nil {
+				return nil, err
+			}
+			rowValue = append(rowValue, excelize.Cell{Value: value})
+		case kindFloat:
+			value, err := strconv.ParseFloat(string(v.([]byte)), 64)
+			if err != nil {
+				return nil, err
+			}
+			rowValue = append(rowValue, excelize.Cell{Value: value})
+
+		// 时间类型,写入为日期单元格,可以排序、筛选和计算
+		case kindDate, kindDateTime:
+			value := v.(time.Time)
+			// 零值日期(0000-00-00)和1900年之前的日期无法表示为Excel日期,写入为文本
+			if value.Year() < 1900 {
+				rowValue = append(rowValue, excelize.Cell{Value: dateText(value, m.columnKinds[i] == kindDate, timeScale(m.columnTypes[i]))})
 			} else {
-				rowValue = append(rowValue, excelize.Cell{Value: valueStr})
+				rowValue = append(rowValue, excelize.Cell{Value: value})
 			}
-			continue
-		}
+		case kindTime:
+			// TIME可以超过24小时或者为负数,转为时长
+			value, err := parseDuration(string(v.([]byte)))
+			if err != nil {
+				return nil, err
+			}
+			rowValue = append(rowValue, excelize.Cell{Value: value})
+		case kindYear:
+			value, err := strconv.ParseInt(string(v.([]byte)), 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			rowValue = append(rowValue, excelize.Cell{Value: value})
 
 		// JSON类型
-		if in(dTypeName, []string{"JSON"}) {
+		case kindJSON:
 			value := string(v.([]byte))
 			rowValue = append(rowValue, excelize.Cell{Value: value})
-			continue
-		}
 
 		// 未测试过或不支持的类型
-		value, ok := v.([]byte)
-		if ok {
-			logger.Warn(fmt.Sprintf("untested database type: %s, column name: %s", dTypeName, m.columnNames[i]))
-			rowValue = append(rowValue, excelize.Cell{Value: string(value)})
-		} else {
-			logger.Fatal(fmt.Sprintf("unsupported database type: %s, column name: %s", dTypeName, m.columnNames[i]))
+		default:
+			dTypeName := m.columnTypes[i].DatabaseTypeName()
+			value, ok := v.([]byte)
+			if ok {
+				logger.Warn(fmt.Sprintf("untested database type: %s, column name: %s", dTypeName, m.columnNames[i]))
+				rowValue = append(rowValue, excelize.Cell{Value: string(value)})
+			} else {
+				logger.Fatal(fmt.Sprintf("unsupported database type: %s, column name: %s", dTypeName, m.columnNames[i]))
+			}
 		}
 	}
 
 	return rowValue, nil
 }
 
-func (m *MySQL) CheckSleep() {
+// ParseText 将一行数据转为文本,用于CSV等文本格式,NULL转为空字符串
+func (m *MySQL) ParseText(row []any) []string {
+	var rowValue []string
+	for i, v := range row {
+		switch value := v.(type) {
+		case nil:
+			rowValue = append(rowValue, "")
+		case []byte:
+			// 二进制类型转为十六进制表示
+			dTypeName := m.columnTypes[i].DatabaseTypeName()
+			if in(dTypeName, []string{"BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "GEOMETRY", "BIT"}) {
+				rowValue = append(rowValue, fmt.Sprintf("0x%X", value))
+			} else if dTypeName == "TIME" {
+				rowValue = append(rowValue, timeText(string(value), timeScale(m.columnTypes[i])))
+			} else {
+				rowValue = append(rowValue, string(value))
+			}
+		case time.Time:
+			rowValue = append(rowValue, dateText(value, m.columnTypes[i].DatabaseTypeName() == "DATE", timeScale(m.columnTypes[i])))
+		default:
+			rowValue = append(rowValue, fmt.Sprint(value))
+		}
+	}
+	return rowValue
+}
+
+func (m *MySQL) CheckSleep(ctx context.Context) {
 	m.rowNextNumber++
 	if m.rowNextNumber >= m.batchSize {
-		time.Sleep(m.delayDuration)
+		// 休眠期间收到信号时立即返回
+		timer := time.NewTimer(m.delayDuration)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
 		m.rowNextNumber = 0
 	}
 }
@@ -205,7 +465,7 @@ type Excel struct {
 	// flags
 	password          string // 设置密码
 	output            string // 输出文件
-	sheetName         string // 单个工作表直接使用此名称,多个工作表会自动添加数字后缀:-N
+	sheetName         string // 单个工作表直接使用此名称,多个工作表会自动添加数字后缀:-N,多条SQL时为逗号分隔的名称列表
 	styleRowHeight    string // 行高
 	styleColWidth     string // 列宽度
 	styleColAlign     string // 列对齐
@@ -216,8 +476,11 @@ type Excel struct {
 	styleColFontColor string // 字体颜色
 	styleColFontSize  string // 字体大小
 
+	colNumFormat []string // 列的数字格式,格式为 列:格式,可以指定多次
+
 	// 存储样式解析结果和表头等一般不会变的数据
 	rowHeightMap    map[int]float64 // 存储行高的Map
+	colWidthMap     map[int]float64 // 存储列宽的Map
 	colAlignMap     map[int]string  // 存储列对齐的Map
 	rowBgColorMap   map[int]string  // 存储背景色的Map
 	colBgColorMap   map[int]string  // 存储背景色的Map
@@ -225,6 +488,7 @@ type Excel struct {
 	rowFontSizeMap  map[int]float64 // 存储字体颜色的Map
 	colFontColorMap map[int]string  // 存储字体颜色的Map
 	colFontSizeMap  map[int]float64 // 存储字体颜色的Map
+	colNumFormatMap map[int]string  // 存储数字格式的Map
 
 	// 表头
 	header []excelize.Cell
@@ -238,12 +502,15 @@ type Excel struct {
 	curSheetLine       int                    // 当前Sheet写入了多少行，不包含表头
 	curSheetHeaderLine int                    // 当前Sheet写入了多少行，包含表头
 	curlTotalLine      int                    // 当前总共写入了多少行，不包含表头
+	curSheetName       string                 // 当前SQL对应的工作表名称
+	curSheetIndex      int                    // 当前SQL在当前Workbook中的第几个工作表
 }
 
 func NewExcel() *Excel {
 	return &Excel{
 		f:               excelize.NewFile(),
 		rowHeightMap:    make(map[int]float64),
+		colWidthMap:     make(map[int]float64),
 		colAlignMap:     make(map[int]string),
 		rowBgColorMap:   make(map[int]string),
 		colBgColorMap:   make(map[int]string),
@@ -251,152 +518,269 @@ func NewExcel() *Excel {
 		colFontColorMap: make(map[int]string),
 		rowFontSizeMap:  make(map[int]float64),
 		colFontSizeMap:  make(map[int]float64),
+		colNumFormatMap: make(map[int]string),
 	}
 }
 
-func (e *Excel) NewStreamWriter() (err error) {
-	e.sw, err = e.f.NewStreamWriter("Sheet1")
-	return err
-}
+// NewStreamWriter 新建一个工作表并初始化StreamWriter,工作簿中的第一个工作表直接使用默认的Sheet1
+func (e *Excel) NewStreamWriter() error {
+	e.curSheetIndex++
+	name := e.getSheetName()
 
-func (e *Excel) getOutput() (string, error) {
-	// 只有一个工作簿的情况下
-	if e.maxWorkbookLine <= 0 || e.curlTotalLine < e.maxWorkbookLine {
-		return e.output, nil
+	if e.sw == nil {
+		err := e.f.SetSheetName("Sheet1", name)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := e.sw.Flush()
+		if err != nil {
+			return err
+		}
+
+		// 同一条SQL有多个工作表时,第一个工作表也需要添加数字后缀
+		if e.curSheetName != "" && e.curSheetIndex == 2 {
+			err = e.renameSheet(e.curSheetName, e.curSheetName+"-1")
+			if err != nil {
+				return err
+			}
+		}
+
+		index, err := e.f.GetSheetIndex(name)
+		if err != nil {
+			return err
+		}
+		if index >= 0 {
+			return fmt.Errorf("duplicate sheet name: %s", name)
+		}
+		_, err = e.f.NewSheet(name)
+		if err != nil {
+			return err
+		}
 	}
 
-	// 转为绝对路径
-	absOutput, err := filepath.Abs(e.output)
+	sw, err := e.f.NewStreamWriter(name)
 	if err != nil {
-		return "", err
+		return err
 	}
+	e.sw = sw
+	e.curSheetLine = 0
+	e.curSheetHeaderLine = 0
 
-	// 绝对路径分割为 路径 和 文件名
-	dir, fileName := filepath.Split(absOutput)
+	// 设置列宽
+	return e.SetColWidth()
+}
 
-	// 文件名分割为 名称 和 扩展名, 名称中允许包含.
-	outputList := strings.Split(fileName, ".")
-	name := strings.Join(outputList[:len(outputList)-1], ".")
-	ext := outputList[len(outputList)-1]
+// getSheetName 新工作表的名称
+// 未指定名称时为SheetN;单个工作表直接使用指定的名称,多个工作表会自动添加数字后缀:-N
+func (e *Excel) getSheetName() string {
+	switch {
+	case e.curSheetName == "" && e.sw == nil:
+		return "Sheet1"
+	case e.curSheetName == "":
+		return "Sheet" + strconv.Itoa(len(e.f.GetSheetList())+1)
+	case e.curSheetIndex == 1:
+		return e.curSheetName
+	default:
+		return e.curSheetName + "-" + strconv.Itoa(e.curSheetIndex)
+	}
+}
 
-	index := math.Ceil(float64(e.curlTotalLine) / float64(e.maxWorkbookLine))
-	indexStr := strconv.FormatFloat(index, 'f', 0, 64)
+func (e *Excel) renameSheet(source, target string) error {
+	index, err := e.f.GetSheetIndex(target)
+	if err != nil {
+		return err
+	}
+	if index >= 0 {
+		return fmt.Errorf("duplicate sheet name: %s", target)
+	}
+	return e.f.SetSheetName(source, target)
+}
 
-	// 组合出新路径
-	newOutput := strings.Join([]string{dir, name, "-", indexStr, ".", ext}, "")
-	return newOutput, nil
+func (e *Excel) getOutput() (string, error) {
+	return partOutput(e.output, e.curlTotalLine, e.maxWorkbookLine)
 }
 
-func (e *Excel) MustClose() {
+func (e *Excel) Close() error {
+	// 还没有写入任何数据
+	if e.sw == nil {
+		return e.f.Close()
+	}
+
 	err := e.sw.Flush()
 	if err != nil {
-		logger.Fatal(err.Error())
+		return err
+	}
+
+	// 一致性快照对应的binlog位置记录在文档属性的备注中
+	if mysql.Snapshot != nil {
+		err = e.f.SetDocProps(&excelize.DocProperties{Description: "consistent snapshot: " + mysql.Snapshot.String()})
+		if err != nil {
+			return err
+		}
+	}
+
+	// 输出到标准输出
+	if isStdout(e.output) {
+		err = e.f.Write(os.Stdout, excelize.Options{Password: e.password})
+		if err != nil {
+			return err
+		}
+		return e.f.Close()
 	}
 
 	output, err := e.getOutput()
 	if err != nil {
-		logger.Fatal(err.Error())
+		return err
 	}
 
-	err = e.f.SaveAs(output, excelize.Options{Password: e.password})
+	// 先写入临时文件,成功后再重命名为输出文件
+	file, err := createTemp(output)
 	if err != nil {
-		logger.Fatal(err.Error())
+		return err
+	}
+	err = e.f.Write(file, excelize.Options{Password: e.password})
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	err = commitTemp(file.Name(), output)
+	if err != nil {
+		return err
 	}
 
-	err = e.f.Close()
+	// 记录生成的文件
+	err = addOutputFile(output, e.curWorkbookLine)
 	if err != nil {
-		logger.Fatal(err.Error())
+		return err
 	}
+
+	return e.f.Close()
 }
 
 func (e *Excel) SetHeader(header []excelize.Cell) {
 	e.header = header
 }
 
+// SetSheetName 设置下一次SetColumns新建的工作表名称,多条SQL时每条SQL调用一次
+func (e *Excel) SetSheetName(name string) {
+	e.curSheetName = name
+	e.curSheetIndex = 0
+}
+
+func (e *Excel) SetColumns(names []string, _ []*sql.ColumnType) error {
+	// 解析样式
+	err := e.ParseStyle()
+	if err != nil {
+		return err
+	}
+
+	// 新建工作表,多条SQL时每条SQL写入一个新的工作表
+	err = e.NewStreamWriter()
+	if err != nil {
+		return err
+	}
+
+	// 设置表头,没有数据时也保留表头
+	var header []excelize.Cell
+	for _, value := range my.cellNames(names) {
+		header = append(header, excelize.Cell{Value: value})
+	}
+	e.SetHeader(header)
+
+	return e.writeHeader()
+}
+
+func (e *Excel) WriteRow(row []any) error {
+	// 遍历每个字段,收集值
+	rowValue, err := my.ParseRow(row)
+	if err != nil {
+		return err
+	}
+
+	// 添加一行到Excel
+	return e.AddRow(rowValue)
+}
+
+// WriteMarker 提示信息写在当前工作表的下一行的第一列,不计入行数,也不会拆分新的工作表或工作簿
+func (e *Excel) WriteMarker(text string) error {
+	return e.writeRow([]excelize.Cell{{Value: text}})
+}
+
 func (e *Excel) AddRow(values []excelize.Cell) error {
 	// 超过工作簿最大行数则重新建一个
 	if e.maxWorkbookLine > 0 && e.curWorkbookLine+1 > e.maxWorkbookLine {
-		// 修改Sheet名称
-		err := e.SetSheetName()
+		// 保存
+		err := e.Close()
 		if err != nil {
-			logger.Fatal(err.Error())
+			return err
 		}
 
-		// 保存
-		e.MustClose()
-
 		e.f = excelize.NewFile()
+		e.sw = nil
+		e.curSheetIndex = 0
+		e.curWorkbookLine = 0
 		err = e.NewStreamWriter()
 		if err != nil {
 			return err
 		}
-		e.curSheetLine = 0
-		e.curWorkbookLine = 0
-		e.curSheetHeaderLine = 0
-
-		err = e.SetStyle()
-		if err != nil {
-			logger.Fatal(err.Error())
-		}
 	}
 
 	// 超过工作表最大行数则重新建一个
 	if e.curSheetLine+1 > e.maxSheetLine {
-		err := e.sw.Flush()
-		if err != nil {
-			return err
-		}
-
-		name := "Sheet" + strconv.Itoa(e.curWorkbookLine/e.maxSheetLine+1)
-		_, err = e.f.NewSheet(name)
+		err := e.NewStreamWriter()
 		if err != nil {
 			return err
 		}
+	}
 
-		e.sw, err = e.f.NewStreamWriter(name)
-		if err != nil {
-			return err
-		}
-
-		e.curSheetLine = 0
-		e.curSheetHeaderLine = 0
-
-		// 重新设置列宽
-		err = e.SetColWidth()
+	// 第一行添加表头
+	if e.curSheetHeaderLine == 0 {
+		err := e.writeHeader()
 		if err != nil {
 			return err
 		}
 	}
 
-	// 第一行添加表头
-	if e.curSheetLine == 0 && len(e.header) > 0 {
-		// 设置样式
-		for i := range e.header {
-			style, err := e.getStyleID(e.curSheetHeaderLine+1, i+1)
-			if err != nil {
-				return err
-			}
-			e.header[i].StyleID = style
-		}
+	// 写入数据
+	err := e.writeRow(values)
+	if err != nil {
+		return err
+	}
 
-		// 类型转换
-		valueAny := e.ConvertAny(e.header)
+	// 计数加1
+	e.curSheetLine++
+	e.curWorkbookLine++
+	e.curlTotalLine++
 
-		// 添加行
-		err := e.sw.SetRow("A1", valueAny, excelize.RowOpts{Height: e.getNextRowHeight()})
-		if err != nil {
-			return err
-		}
-		e.curSheetHeaderLine++
-	}
+	return nil
+}
 
+// writeRow 写入一行到当前工作表的下一行
+func (e *Excel) writeRow(values []excelize.Cell) error {
 	// 设置颜色样式
 	for i := range values {
-		style, err := e.getStyleID(e.curSheetHeaderLine+1, i+1)
+		// TIME写入为天数,Excel无法显示负数的时间,负数写入为文本
+		if d, ok := values[i].Value.(time.Duration); ok {
+			if d < 0 {
+				values[i].Value = clockText(d, timeScale(my.columnTypes[my.cellColumn(i)]))
+			} else {
+				values[i].Value = excelDays(d)
+			}
+		}
+
+		style, err := e.getStyleID(e.curSheetHeaderLine+1, i+1, e.getNumFmt(i+1, values[i].Value))
 		if err != nil {
 			return err
 		}
 		values[i].StyleID = style
+
+		// 估算写入的字节数
+		outputSize += cellSize(values[i].Value)
 	}
 
 	// 类型转换
@@ -408,13 +792,69 @@ func (e *Excel) AddRow(values []excelize.Cell) error {
 	if err != nil {
 		return err
 	}
+	e.curSheetHeaderLine++
 
-	// 计数加1
-	e.curSheetLine++
+	return nil
+}
+
+// writeHeader 写入表头到当前工作表的第一行
+func (e *Excel) writeHeader() error {
+	if len(e.header) == 0 {
+		return nil
+	}
+
+	// 设置样式
+	for i := range e.header {
+		style, err := e.getStyleID(e.curSheetHeaderLine+1, i+1, "")
+		if err != nil {
+			return err
+		}
+		e.header[i].StyleID = style
+	}
+
+	// 类型转换
+	valueAny := e.ConvertAny(e.header)
+
+	// 添加行
+	err := e.sw.SetRow("A1", valueAny, excelize.RowOpts{Height: e.getNextRowHeight()})
+	if err != nil {
+		return err
+	}
 	e.curSheetHeaderLine++
-	e.curWorkbookLine++
-	e.curlTotalLine++
 
+	// ENUM列添加下拉列表
+	if my.enumDropdown {
+		return e.addEnumDropdown()
+	}
+	return nil
+}
+
+// addEnumDropdown 为当前工作表的ENUM列添加数据验证,从第二行到最后一行只能选择ENUM允许的值
+// 数据验证在StreamWriter.Flush时写入,所以必须在写入数据之前调用
+func (e *Excel) addEnumDropdown() error {
+	for i, col := range my.cellColumns {
+		meta := my.columnMetas[col]
+		if my.columnKinds[col] != kindEnum || meta == nil || len(meta.values) == 0 {
+			continue
+		}
+
+		// 下拉列表以逗号分隔,最多255个字符
+		dv := excelize.NewDataValidation(true)
+		err := dv.SetDropList(meta.values)
+		if err != nil || strings.Contains(strings.Join(meta.values, ""), ",") {
+			logger.Warn("the ENUM values are too long or contain commas, skip the dropdown", zap.String("column", my.columnNames[col]))
+			continue
+		}
+		name, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+		dv.SetSqref(name + "2:" + name + strconv.Itoa(excelize.TotalRows))
+		err = e.f.AddDataValidation(e.sw.Sheet, dv)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -426,8 +866,10 @@ func (e *Excel) ConvertAny(cells []excelize.Cell) []any {
 	return values
 }
 
-func (e *Excel) SetStyle() error {
-	err := e.SetColWidth()
+// ParseStyle 解析样式并存储到Map中,不依赖StreamWriter,其他输出格式也可以使用
+func (e *Excel) ParseStyle() error {
+	// 设置列宽
+	err := e.SetColWidthMap()
 	if err != nil {
 		return err
 	}
@@ -480,6 +922,12 @@ func (e *Excel) SetStyle() error {
 		return err
 	}
 
+	// 设置列数字格式
+	err = e.SetColNumFormat()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -515,6 +963,37 @@ func (e *Excel) SetColWidth() error {
 	return nil
 }
 
+func (e *Excel) SetColWidthMap() error {
+	list, err := e.parseStyle(e.styleColWidth)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range list {
+		minStr, maxStr, widthStr := item[0], item[1], item[2]
+
+		min, err := strconv.Atoi(minStr)
+		if err != nil {
+			return err
+		}
+
+		max, err := strconv.Atoi(maxStr)
+		if err != nil {
+			return err
+		}
+
+		width, err := strconv.ParseFloat(widthStr, 10)
+		if err != nil {
+			return err
+		}
+
+		for i := min; i <= max; i++ {
+			e.colWidthMap[i] = width
+		}
+	}
+	return nil
+}
+
 func (e *Excel) SetColAlign() error {
 	list, err := e.parseStyle(e.styleColAlign)
 	if err != nil {
@@ -738,6 +1217,36 @@ func (e *Excel) SetColFontSize() error {
 	return nil
 }
 
+// SetColNumFormat 解析--col-num-format,格式本身可能包含冒号和逗号,比如 3:yyyy-mm-dd hh:mm、4-5:#,##0.00,所以每个列单独指定一次
+func (e *Excel) SetColNumFormat() error {
+	for _, element := range e.colNumFormat {
+		key, numFmt, ok := strings.Cut(element, ":")
+		if !ok || numFmt == "" {
+			return fmt.Errorf("parse --col-num-format error: %s", element)
+		}
+
+		minStr, maxStr, ok := strings.Cut(key, "-")
+		if !ok {
+			maxStr = minStr
+		}
+
+		min, err := strconv.Atoi(minStr)
+		if err != nil {
+			return fmt.Errorf("parse --col-num-format error: %s", element)
+		}
+
+		max, err := strconv.Atoi(maxStr)
+		if err != nil {
+			return fmt.Errorf("parse --col-num-format error: %s", element)
+		}
+
+		for i := min; i <= max; i++ {
+			e.colNumFormatMap[i] = numFmt
+		}
+	}
+	return nil
+}
+
 func (e *Excel) parseStyle(style string) (list [][]string, err error) {
 	if style == "" {
 		return
@@ -766,10 +1275,20 @@ func (e *Excel) parseStyle(style string) (list [][]string, err error) {
 	return
 }
 
-func (e *Excel) getStyleID(rowIndex, colIndex int) (int, error) {
+// getStyleID 生成单元格样式,numFmt为单元格的数字格式,为空时使用默认格式
+func (e *Excel) getStyleID(rowIndex, colIndex int, numFmt string) (int, error) {
 	// 样式对象
 	style := &excelize.Style{}
 
+	// 数字格式,文本格式(@)使用内置格式,编辑单元格时Excel也不会转为数字或者科学计数法
+	switch numFmt {
+	case "":
+	case "@":
+		style.NumFmt = 49
+	default:
+		style.CustomNumFmt = &numFmt
+	}
+
 	// 对齐样式
 	align, ok := e.colAlignMap[colIndex]
 	if !ok {
@@ -829,28 +1348,38 @@ func (e *Excel) getStyleID(rowIndex, colIndex int) (int, error) {
 	return styleID, err
 }
 
+// getNumFmt 获取单元格的数字格式,文本总是使用文本格式,其他值优先使用--col-num-format,
+// 日期时间默认为 yyyy-mm-dd hh:mm:ss,TIME默认为 [h]:mm:ss,有小数秒时最多显示3位
+func (e *Excel) getNumFmt(colIndex int, value any) string {
+	switch value.(type) {
+	case nil:
+		return ""
+	case string:
+		return "@"
+	}
+	if numFmt, ok := e.colNumFormatMap[colIndex]; ok {
+		return numFmt
+	}
+	col := my.cellColumn(colIndex - 1)
+	if col < 0 {
+		return ""
+	}
+	switch my.columnKinds[col] {
+	case kindDate:
+		return defaultDateFormat
+	case kindDateTime:
+		return defaultDateTimeFormat + fracFormat(timeScale(my.columnTypes[col]))
+	case kindTime:
+		return defaultTimeFormat + fracFormat(timeScale(my.columnTypes[col]))
+	}
+	return ""
+}
+
 func (e *Excel) getNextRowHeight() float64 {
 	height, _ := e.rowHeightMap[e.curSheetHeaderLine+1]
 	return height
 }
 
-func (e *Excel) SetSheetName() error {
-	if e.sheetName == "" {
-		return nil
-	}
-	sheetList := e.f.GetSheetList()
-	if len(sheetList) <= 1 {
-		return e.f.SetSheetName("Sheet1", e.sheetName)
-	}
-	for i, v := range sheetList {
-		err := e.f.SetSheetName(v, e.sheetName+"-"+strconv.Itoa(i+1))
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 var rootCmd = &cobra.Command{
 	Use:           "mysqlexport",
 	Short:         "Export mysql to excel\nFor details, please refer to https://github.com/vvfock3r/mysqlexport",
@@ -861,6 +1390,11 @@ var rootCmd = &cobra.Command{
 			m.MustCheck(cmd)
 		}
 
+		// 输出到标准输出时,日志改为输出到标准错误,避免破坏输出的数据
+		if isStdout(excel.output) {
+			logger.UseStderr()
+		}
+
 		for _, m := range load.ModuleList {
 			err := m.Initialize(cmd)
 			if err != nil {
@@ -871,78 +1405,217 @@ var rootCmd = &cobra.Command{
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		// 解析SQL
+		err := my.ParseStatements()
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+		sheetNames, err := getSheetNames(my.statements)
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+
+		// 根据输出格式初始化写入器
+		writer, err := NewWriter()
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+
 		// 连接数据库
-		err := my.Ping()
+		err = my.Ping()
 		if err != nil {
 			logger.Fatal("connect database error", zap.Error(err))
 		}
 		logger.Info("connect database success")
 		defer func() { _ = my.Close() }()
 
-		// 执行SQL
-		err = my.Query()
+		// 检查执行计划
+		err = explain.Check(my.statements, my.params)
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+
+		// 开启一致性快照,所有SQL读取同一时间点的数据
+		err = mysql.StartSnapshot(context.Background())
+		if err != nil {
+			logger.Fatal("start consistent snapshot error", zap.Error(err))
+		}
+		defer func() { _ = mysql.EndSnapshot() }()
+
+		// 导出,收到SIGINT或SIGTERM时停止读取,保存已经导出的数据
+		// 导出之前还没有写入任何数据,直接结束程序即可,事务会随着连接断开而回滚
+		ctx, stop := signalContext(context.Background())
+		err = export(ctx, writer, sheetNames)
+		stop()
+		if err != nil {
+			// 保存已经导出的数据,输出中已经写入了提示信息,然后释放连接,os.Exit不会执行defer
+			code := 1
+			if errors.Is(err, errInterrupted) {
+				code = exitInterrupted
+				logger.Warn("export interrupted", zap.Error(err))
+			} else {
+				// 出错时不使用输出文件的名称,避免下游任务读取到不完整的数据
+				partialOutput = true
+				logger.Error("export error, the exported data is saved with the suffix "+partialExt, zap.Error(err))
+			}
+			closeErr := writer.Close()
+			if closeErr != nil {
+				logger.Error("save error", zap.Error(closeErr))
+			}
+			removeTempFiles()
+			_ = mysql.EndSnapshot()
+			_ = my.Close()
+			os.Exit(code)
+		}
+
+		// 保存
+		err = writer.Close()
 		if err != nil {
 			logger.Fatal(err.Error())
 		}
-		defer func() { _ = my.rows.Close() }()
 
-		// 初始化Excel流式写入器
-		err = excel.NewStreamWriter()
+		// 打包
+		err = bundle.Close()
 		if err != nil {
 			logger.Fatal(err.Error())
 		}
-		defer excel.MustClose()
 
-		// 设置样式
-		err = excel.SetStyle()
+		// 导出完成,删除断点续传的检查点
+		err = resume.Done()
 		if err != nil {
 			logger.Fatal(err.Error())
 		}
 
-		// 设置表头
-		var header []excelize.Cell
-		for i, value := range my.columnNames {
-			style, err := excel.getStyleID(1, i+1)
-			if err != nil {
-				logger.Fatal(err.Error())
-			}
-			header = append(header, excelize.Cell{Value: value, StyleID: style})
+		// 结束
+		logger.Info("execution completed")
+	},
+}
+
+// export 依次执行每条SQL并写入,多条SQL时每条SQL写入一个工作表
+// 收到信号时返回context.Cause,如果已经写入了数据,会在输出中写入提示信息,标记数据不完整
+func export(ctx context.Context, writer Writer, sheetNames []string) (err error) {
+	started := false
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+		if err != nil && started {
+			_ = writer.WriteMarker("the export is interrupted: " + err.Error())
+		}
+	}()
+
+	for i, statement := range my.statements {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+
+		// 超过输出大小限制时不再执行后面的SQL
+		if limit.OutputExceeded() {
+			logger.Warn("the output exceeds the --max-output-size, skip the remaining sql statements")
+			break
 		}
-		excel.SetHeader(header)
 
-		// 遍历每一条记录
-		for my.rows.Next() {
-			// 获取一行
-			row, err := my.rows.SliceScan()
+		// 设置工作表名称
+		if sw, ok := writer.(SheetWriter); ok {
+			sw.SetSheetName(sheetNames[i])
+		}
+
+		// 执行SQL,指定了--resume-key时分页查询,每页执行一次
+		my.execute = statement.SQL
+		var rows int
+		columns := false
+		for {
+			err = my.Query(ctx)
 			if err != nil {
-				logger.Fatal(err.Error())
+				// 临时错误重新连接后重新执行
+				err = retry.Reconnect(ctx, err)
+				if err != nil {
+					return err
+				}
+				continue
 			}
 
-			// 遍历每个字段,收集值
-			rowValue, err := my.ParseRow(row)
-			if err != nil {
-				logger.Fatal(err.Error())
+			// 设置表头
+			if !columns {
+				err = resume.SetColumns(my.columnNames, my.columnTypes)
+				if err == nil {
+					err = writer.SetColumns(my.columnNames, my.columnTypes)
+				}
+				if err != nil {
+					my.CloseRows(true)
+					return err
+				}
+				columns = true
+				started = true
 			}
 
-			// 添加一行到Excel
-			err = excel.AddRow(rowValue)
+			// 遍历每一条记录
+			n, stop, err := exportRows(ctx, writer, rows)
+			my.CloseRows(stop)
+			rows += n
 			if err != nil {
-				logger.Fatal(err.Error())
+				// 读取数据时连接断开,指定了--resume-key时从最后写入的一行继续查询,否则只有还没有写入数据时才能重新执行
+				if !resume.Enabled() && rows > 0 {
+					return err
+				}
+				if n > 0 {
+					retry.Reset()
+				}
+				err = retry.Reconnect(ctx, err)
+				if err != nil {
+					return err
+				}
+				continue
 			}
+			retry.Reset()
+
+			// 超过限制或者已经是最后一页
+			if stop || !resume.Enabled() || n < my.batchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
 
-			// 是否休眠一下以减轻MySQL的压力
-			my.CheckSleep()
+// exportRows 遍历当前结果集的每一条记录并写入,超过限制时停止读取,并在输出中写入提示信息
+// exported为当前SQL之前的分页已经导出的行数,返回值n为本次导出的行数,stop代表是否还有没有读取的数据
+func exportRows(ctx context.Context, writer Writer, exported int) (n int, stop bool, err error) {
+	for my.rows.Next() {
+		// 收到信号
+		if ctx.Err() != nil {
+			return n, true, context.Cause(ctx)
 		}
 
-		// 修改Sheet名称
-		err = excel.SetSheetName()
+		// 还有数据,但是超过了限制
+		if reason := limit.Check(resume.resumed + exported + n); reason != "" {
+			logger.Warn(reason)
+			return n, true, writer.WriteMarker(reason)
+		}
+
+		// 获取一行
+		row, err := my.SliceScan()
 		if err != nil {
-			logger.Fatal(err.Error())
+			return n, true, err
 		}
 
-		// 结束
-		logger.Info("execution completed")
-	},
+		// 写入一行
+		err = writer.WriteRow(row)
+		if err != nil {
+			return n, true, err
+		}
+		n++
+
+		// 记录断点续传的键
+		err = resume.Track(row)
+		if err != nil {
+			return n, true, err
+		}
+
+		// 是否休眠一下以减轻MySQL的压力
+		my.CheckSleep(ctx)
+	}
+	return n, false, limitError(my.rows.Err())
 }
 
 func in(str string, list []string) bool {
@@ -961,19 +1634,46 @@ func init() {
 	}
 
 	// mysql flags
-	rootCmd.Flags().StringVarP(&my.execute, "execute", "e", "", "specifies the SQL command to be executed.")
+	rootCmd.Flags().StringArrayVarP(&my.executeList, "execute", "e", nil, "specifies the SQL command to be executed, - means stdin, can be specified multiple times")
+	rootCmd.Flags().StringVar(&my.sqlFile, "sql-file", "", "specifies the file containing the SQL commands to be executed, separated by semicolons, - means stdin")
+	rootCmd.Flags().StringArrayVar(&my.paramList, "param", nil, "specifies the parameter bound to the ? or :name placeholder in the SQL command, format: name=[int:|float:|date:|str:]value, can be specified multiple times")
 	rootCmd.Flags().IntVarP(&my.batchSize, "batch-size", "", 10000, "specifies the batch size to use when executing SQL commands")
 	rootCmd.Flags().StringVarP(&my.delayTime, "delay-time", "", "1s", "specifies the time to delay between batches when executing SQL")
+	rootCmd.Flags().IntVar(&retry.maxRetries, "max-retries", 3, "specifies the maximum number of consecutive retries on transient MySQL errors, such as lost connection, lock wait timeout and deadlock")
+	rootCmd.Flags().StringVar(&retry.backoff, "retry-backoff", "1s", "specifies the time to wait before the first retry, doubled on each retry with random jitter")
 
-	err := rootCmd.MarkFlagRequired("execute")
-	if err != nil {
-		panic(err)
-	}
+	// explain flags
+	rootCmd.Flags().BoolVar(&explain.enable, "explain", false, "specifies whether to check the execution plan with EXPLAIN before exporting")
+	rootCmd.Flags().Int64Var(&explain.maxRows, "explain-max-rows", 0, "specifies the maximum number of rows estimated to be examined per table, the query will be refused if exceeded")
+	rootCmd.Flags().StringVar(&explain.refuse, "explain-refuse", "", "specifies the execution plans to be refused, separated by commas: full-scan, filesort, temporary")
+	rootCmd.Flags().BoolVar(&explain.force, "force", false, "specifies whether to run the query anyway when it is refused by the execution plan check")
+
+	// resume flags
+	rootCmd.Flags().StringVar(&resume.key, "resume-key", "", "specifies the ordered unique column to page the query by, a checkpoint is written after each completed file when using --workbook-line")
+	rootCmd.Flags().BoolVar(&resume.resume, "resume", false, "specifies whether to resume the export from the checkpoint written by --resume-key, must be used with --workbook-line")
+
+	// limit flags
+	rootCmd.Flags().IntVar(&limit.maxRows, "max-rows", 0, "specifies the maximum number of rows to export per SQL command, the rest will be truncated with a marker in the output")
+	rootCmd.Flags().StringVar(&limit.maxOutputSize, "max-output-size", "", "specifies the maximum size of the output, e.g. 100MB, the rest will be truncated with a marker in the output")
+
+	// output flags
+	rootCmd.Flags().StringVarP(&excel.output, "output", "o", "", "specifies the name of the output file, - means stdout")
+	rootCmd.Flags().StringVar(&format, "format", "", "specifies the output format: xlsx, ods, csv, tsv, jsonl, parquet, sql, html, md (default detected from the output file extension)")
+	rootCmd.Flags().BoolVar(&overwrite, "overwrite", false, "specifies whether to overwrite the existing output files")
+
+	rootCmd.Flags().StringVar(&compress, "compress", "", "specifies the compression for text formats: gzip, zstd (default detected from the output file extension)")
+	rootCmd.Flags().StringVar(&bundle.format, "bundle", "", "specifies the bundle format to collect all generated files and a manifest into one archive: zip")
+	rootCmd.Flags().StringVar(&bundle.password, "bundle-password", "", "specifies the password for the bundle, encrypted with AES-256")
 
 	// excel flags
-	rootCmd.Flags().StringVarP(&excel.output, "output", "o", "", "specifies the name of the output Excel file")
 	rootCmd.Flags().StringVar(&excel.password, "setup-password", "", "specifies the password for the Excel file")
-	rootCmd.Flags().StringVar(&excel.sheetName, "sheet-name", "", "specifies the name of the sheet in the Excel file")
+	rootCmd.Flags().StringVar(&my.decimalPolicy, "decimal-policy", "auto", "specifies how to write DECIMAL values to the cells: auto (number if it has at most 15 significant digits, otherwise text), number, text")
+	rootCmd.Flags().StringVar(&my.numericCols, "numeric-cols", "", "specifies the string columns to write as numbers to the cells, column names or numbers separated by commas")
+	rootCmd.Flags().StringVar(&my.bitFormat, "bit-format", "int", "specifies how to write BIT(n) values to the cells: int, binary, BIT(1) is always written as a boolean")
+	rootCmd.Flags().StringVar(&my.setFormat, "set-format", "list", "specifies how to write SET values to the cells: list, onehot (one boolean column per value)")
+	rootCmd.Flags().StringVar(&my.setDelimiter, "set-delimiter", ",", "specifies the delimiter of the SET values when using --set-format list")
+	rootCmd.Flags().BoolVar(&my.enumDropdown, "enum-dropdown", false, "specifies whether to add a dropdown list of the allowed values to the ENUM columns in the Excel file")
+	rootCmd.Flags().StringVar(&excel.sheetName, "sheet-name", "", "specifies the name of the sheet in the Excel file, separated by commas for multiple SQL commands")
 	rootCmd.Flags().IntVarP(&excel.maxSheetLine, "sheet-line", "", 1000000, "specifies the maximum number of lines per sheet in the Excel file")
 	rootCmd.Flags().IntVarP(&excel.maxWorkbookLine, "workbook-line", "", -1, "specifies the maximum number of lines all sheet in the Excel file")
 	rootCmd.Flags().StringVar(&excel.styleRowHeight, "row-height", "", "specifies the row height in the Excel file")
@@ -985,8 +1685,27 @@ func init() {
 	rootCmd.Flags().StringVar(&excel.styleColBgColor, "col-bg-color", "", "specifies column background color in the Excel file")
 	rootCmd.Flags().StringVar(&excel.styleColFontColor, "col-font-color", "", "specifies column font color in the Excel file")
 	rootCmd.Flags().StringVar(&excel.styleColFontSize, "col-font-size", "", "specifies column font size in the Excel file")
+	rootCmd.Flags().StringArrayVar(&excel.colNumFormat, "col-num-format", nil, "specifies the column number format in the Excel file, e.g. 3:yyyy-mm-dd hh:mm, can be specified multiple times")
+
+	// csv flags
+	rootCmd.Flags().StringVar(&csv.delimiter, "delimiter", "", "specifies the field delimiter in the CSV/TSV file (default \",\" for csv, \"\\t\" for tsv)")
+	rootCmd.Flags().StringVar(&csv.quote, "quote", "\"", "specifies the quote character in the CSV/TSV file")
+	rootCmd.Flags().StringVar(&csv.lineEnding, "line-ending", "lf", "specifies the line ending in the CSV/TSV file: lf, crlf")
+	rootCmd.Flags().BoolVar(&csv.bom, "bom", false, "specifies whether to write the UTF-8 BOM in the CSV/TSV file")
+
+	// parquet flags
+	rootCmd.Flags().StringVar(&pq.compression, "parquet-compression", "snappy", "specifies the compression codec in the Parquet file: none, snappy, gzip, zstd")
+	rootCmd.Flags().StringVar(&pq.rowGroupSize, "parquet-row-group-size", "64MB", "specifies the row group size in the Parquet file")
+
+	// sql flags
+	rootCmd.Flags().StringVar(&dump.table, "sql-table", "", "specifies the table name in the INSERT statements (default the name of the output file)")
+	rootCmd.Flags().IntVar(&dump.batchSize, "sql-batch-size", 100, "specifies the number of rows per INSERT statement")
+	rootCmd.Flags().BoolVar(&dump.createTable, "sql-create-table", false, "specifies whether to generate the CREATE TABLE statement")
+
+	// markdown flags
+	rootCmd.Flags().IntVar(&md.maxWidth, "md-max-width", 0, "specifies the maximum width of the cells in the Markdown table, longer values will be truncated")
 
-	err = rootCmd.MarkFlagRequired("output")
+	err := rootCmd.MarkFlagRequired("output")
 	if err != nil {
 		panic(err)
 	}
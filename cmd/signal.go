@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/vvfock3r/mysqlexport/kernel/module/logger"
+)
+
+// exitInterrupted 收到信号中断导出时的退出码,与Shell中被SIGINT终止的退出码一致
+const exitInterrupted = 130
+
+// errInterrupted 收到信号后中断导出
+var errInterrupted = errors.New("received a signal")
+
+// signalContext 返回收到SIGINT或SIGTERM时会取消的context,context.Cause为包装了errInterrupted的错误
+// 第一次收到信号后恢复默认的信号处理,再次按下Ctrl-C会直接结束程序
+func signalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			logger.Warn("received signal, stop exporting and save the partial output, press Ctrl-C again to exit immediately", zap.String("signal", sig.String()))
+			cancel(fmt.Errorf("%w: %s", errInterrupted, sig))
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel(context.Canceled)
+	}
+}
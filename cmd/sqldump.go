@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sqlEscaper 按照MySQL的规则转义字符串
+var sqlEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"'", "\\'",
+	"\"", "\\\"",
+	"\x00", "\\0",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\x1a", "\\Z",
+)
+
+// SQLDump 将查询结果转为INSERT语句,用于将数据重新导入到其他数据库
+type SQLDump struct {
+	// flags
+	table       string // 表名称,默认使用输出文件的名称
+	batchSize   int    // 每条INSERT语句包含多少行
+	createTable bool   // 是否生成CREATE TABLE语句
+
+	// 与Excel共用的flags
+	output      string // 输出文件
+	maxFileLine int    // 每个文件最多允许写入多少行
+
+	insert   string            // INSERT语句的开头部分
+	create   string            // CREATE TABLE语句
+	types    []*sql.ColumnType // 列类型
+	curBatch int               // 当前INSERT语句写入了多少行
+	file     *TextFile
+}
+
+func NewSQLDump() *SQLDump {
+	return &SQLDump{}
+}
+
+func (d *SQLDump) Init() error {
+	if d.batchSize <= 0 {
+		return fmt.Errorf("the sql batch size must be greater than 0")
+	}
+
+	// 默认使用输出文件的名称作为表名称
+	if d.table == "" && isStdout(d.output) {
+		return fmt.Errorf("the --sql-table must be specified when writing to stdout")
+	}
+	if d.table == "" {
+		fileName := filepath.Base(d.output)
+		d.table = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	}
+
+	d.file = NewTextFile(d.output, d.maxFileLine)
+	d.file.onOpen = d.writeHeader
+	d.file.onClose = d.writeFooter
+
+	return nil
+}
+
+func (d *SQLDump) SetColumns(names []string, types []*sql.ColumnType) error {
+	var quoted []string
+	for _, name := range names {
+		quoted = append(quoted, quoteIdentifier(name))
+	}
+	table := quoteTable(d.table)
+
+	d.insert = "INSERT INTO " + table + " (" + strings.Join(quoted, ", ") + ") VALUES\n"
+	d.types = types
+
+	// CREATE TABLE语句,结果集中没有字符串长度等信息,字符串统一使用TEXT
+	if d.createTable {
+		var b strings.Builder
+		b.WriteString("CREATE TABLE IF NOT EXISTS " + table + " (\n")
+		for i, columnType := range types {
+			b.WriteString("  " + quoted[i] + " " + d.parseType(columnType))
+			if nullable, ok := columnType.Nullable(); ok && !nullable {
+				b.WriteString(" NOT NULL")
+			}
+			if i < len(types)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(");\n\n")
+		d.create = b.String()
+	}
+
+	return d.file.Open()
+}
+
+func (d *SQLDump) WriteRow(row []any) error {
+	w, err := d.file.Next()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if d.curBatch == 0 {
+		b.WriteString(d.insert)
+	} else {
+		b.WriteString(",\n")
+	}
+
+	text := my.ParseText(row)
+	b.WriteString("(")
+	for i, v := range row {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(d.quoteValue(i, v, text[i]))
+	}
+	b.WriteString(")")
+
+	// 达到批量大小则结束当前INSERT语句
+	d.curBatch++
+	if d.curBatch >= d.batchSize {
+		b.WriteString(";\n")
+		d.curBatch = 0
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarker 结束未完成的INSERT语句,提示信息写为注释
+func (d *SQLDump) WriteMarker(text string) error {
+	w, err := d.file.Writer()
+	if err != nil {
+		return err
+	}
+	err = d.writeFooter(w)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "-- "+strings.Join(strings.Fields(text), " ")+"\n")
+	return err
+}
+
+func (d *SQLDump) Close() error {
+	return d.file.Close()
+}
+
+// writeHeader 每个新文件都需要写入CREATE TABLE语句
+func (d *SQLDump) writeHeader(w io.Writer) error {
+	_, err := io.WriteString(w, d.create)
+	return err
+}
+
+// writeFooter 结束未完成的INSERT语句
+func (d *SQLDump) writeFooter(w io.Writer) error {
+	if d.curBatch == 0 {
+		return nil
+	}
+	d.curBatch = 0
+	_, err := io.WriteString(w, ";\n")
+	return err
+}
+
+// parseType 根据MySQL列类型生成CREATE TABLE中的类型定义
+func (d *SQLDump) parseType(columnType *sql.ColumnType) string {
+	dTypeName := columnType.DatabaseTypeName()
+	switch dTypeName {
+	case "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED INT", "UNSIGNED BIGINT":
+		return strings.TrimPrefix(dTypeName, "UNSIGNED ") + " UNSIGNED"
+	case "DECIMAL":
+		precision, scale := decimalSize(columnType)
+		return fmt.Sprintf("DECIMAL(%d,%d)", precision, scale)
+	case "DATETIME", "TIMESTAMP", "TIME":
+		_, scale, ok := columnType.DecimalSize()
+		if ok && scale > 0 {
+			return fmt.Sprintf("%s(%d)", dTypeName, scale)
+		}
+		return dTypeName
+	case "CHAR", "VARCHAR":
+		return "TEXT"
+	case "BINARY", "VARBINARY":
+		return "BLOB"
+	case "BIT":
+		return "BIT(64)"
+	case "":
+		return "TEXT"
+	default:
+		return dTypeName
+	}
+}
+
+// quoteValue 将一个字段转为SQL字面量
+func (d *SQLDump) quoteValue(i int, v any, text string) string {
+	// 空值
+	if v == nil {
+		return "NULL"
+	}
+
+	dTypeName := d.types[i].DatabaseTypeName()
+
+	// 时间类型,保留小数秒
+	if t, ok := v.(time.Time); ok {
+		return "'" + dateText(t, dTypeName == "DATE", timeScale(d.types[i])) + "'"
+	}
+
+	// TIME使用MySQL的原始格式,不转为ISO 8601
+	if value, ok := v.([]byte); ok && dTypeName == "TIME" {
+		return "'" + string(value) + "'"
+	}
+
+	// 数字类型原样输出
+	if in(dTypeName, []string{
+		"TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT",
+		"UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED INT", "UNSIGNED BIGINT",
+		"DECIMAL", "FLOAT", "DOUBLE", "YEAR",
+	}) {
+		return text
+	}
+
+	// 二进制类型使用十六进制字面量
+	if value, ok := v.([]byte); ok && in(dTypeName, []string{"BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "GEOMETRY", "BIT"}) {
+		return fmt.Sprintf("X'%X'", value)
+	}
+
+	// 其他类型均作为字符串
+	return "'" + sqlEscaper.Replace(text) + "'"
+}
+
+// zeroDigit 将数字替换为0
+func zeroDigit(r rune) rune {
+	if r >= '0' && r <= '9' {
+		return '0'
+	}
+	return r
+}
+
+// quoteIdentifier 使用反引号包裹标识符
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// quoteTable 表名称中可以包含数据库名称,比如 db.table
+func quoteTable(table string) string {
+	var list []string
+	for _, name := range strings.Split(table, ".") {
+		list = append(list, quoteIdentifier(name))
+	}
+	return strings.Join(list, ".")
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+)
+
+// sheetCommentRegexp 匹配 -- sheet: Name 形式的注释,用于指定工作表名称
+var sheetCommentRegexp = regexp.MustCompile(`(?i)^\s*sheet\s*:\s*(.*?)\s*$`)
+
+// Statement 一条需要执行的SQL
+type Statement struct {
+	SQL   string // SQL语句,不包含结尾的分号
+	Sheet string // 工作表名称,来自 -- sheet: Name 注释
+}
+
+// splitStatements 按分号拆分多条SQL,忽略引号和注释中的分号
+// 注释会被去掉, -- sheet: Name 注释用于指定其后SQL的工作表名称, /*! */ 和 /*+ */ 对MySQL有意义,会保留
+func splitStatements(text string) []Statement {
+	var (
+		list  []Statement
+		sheet string
+		b     strings.Builder
+	)
+
+	flush := func() {
+		s := strings.TrimSpace(b.String())
+		if s != "" {
+			list = append(list, Statement{SQL: s, Sheet: sheet})
+			sheet = ""
+		}
+		b.Reset()
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		// 字符串和标识符
+		case c == '\'' || c == '"' || c == '`':
+			end := scanQuoted(text, i)
+			b.WriteString(text[i:end])
+			i = end
+
+		// 单行注释: -- 后面必须是空白字符
+		case strings.HasPrefix(text[i:], "--") && (i+2 == len(text) || isSpace(text[i+2])), c == '#':
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			if c == '-' {
+				match := sheetCommentRegexp.FindStringSubmatch(text[i+2 : i+end])
+				if match != nil {
+					sheet = match[1]
+				}
+			}
+			b.WriteByte(' ')
+			i += end
+
+		// 多行注释
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				end = len(text)
+			} else {
+				end += i + 4
+			}
+			if strings.HasPrefix(text[i:], "/*!") || strings.HasPrefix(text[i:], "/*+") {
+				b.WriteString(text[i:end])
+			} else {
+				b.WriteByte(' ')
+			}
+			i = end
+
+		// 语句结束
+		case c == ';':
+			flush()
+			i++
+
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+
+	return list
+}
+
+// scanQuoted 返回引号结束后的位置,支持反斜杠转义和两个引号连写的转义,反引号中不支持反斜杠转义
+func scanQuoted(text string, start int) int {
+	return scanQuotedEscape(text, start, true)
+}
+
+// scanQuotedEscape 与scanQuoted相同,backslashEscapes为false时反斜杠不是转义字符,与sql_mode为NO_BACKSLASH_ESCAPES时一致
+func scanQuotedEscape(text string, start int, backslashEscapes bool) int {
+	quote := text[start]
+	for i := start + 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if quote != '`' && backslashEscapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(text) && text[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
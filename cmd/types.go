@@ -0,0 +1,358 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// columnKind 列的类型分类,由数据库类型名称映射而来,同一类的类型转换方式相同
+type columnKind int
+
+const (
+	kindUnknown  columnKind = iota // 未测试过的类型
+	kindString                     // CHAR、VARCHAR、TEXT等
+	kindBinary                     // BINARY、VARBINARY、BLOB等、GEOMETRY
+	kindInt                        // 有符号整数
+	kindUint                       // 无符号整数
+	kindDecimal                    // DECIMAL
+	kindFloat                      // FLOAT、DOUBLE
+	kindBit                        // BIT
+	kindDate                       // DATE
+	kindDateTime                   // DATETIME、TIMESTAMP
+	kindTime                       // TIME
+	kindYear                       // YEAR
+	kindJSON                       // JSON
+	kindNumeric                    // --numeric-cols 指定的字符串列
+	kindEnum                       // ENUM
+	kindSet                        // SET
+)
+
+// columnKindMap 数据库类型名称与类型分类的对应关系
+var columnKindMap = map[string]columnKind{
+	"CHAR":       kindString,
+	"VARCHAR":    kindString,
+	"TINYTEXT":   kindString,
+	"TEXT":       kindString,
+	"MEDIUMTEXT": kindString,
+	"LONGTEXT":   kindString,
+	"BINARY":     kindBinary,
+	"VARBINARY":  kindBinary,
+	"TINYBLOB":   kindBinary,
+	"BLOB":       kindBinary,
+	"MEDIUMBLOB": kindBinary,
+	"LONGBLOB":   kindBinary,
+	"GEOMETRY":   kindBinary,
+	"TINYINT":    kindInt,
+	"SMALLINT":   kindInt,
+	"MEDIUMINT":  kindInt,
+	"INT":        kindInt,
+	"BIGINT":     kindInt,
+	"DECIMAL":    kindDecimal,
+	"FLOAT":      kindFloat,
+	"DOUBLE":     kindFloat,
+	"BIT":        kindBit,
+	"DATE":       kindDate,
+	"DATETIME":   kindDateTime,
+	"TIMESTAMP":  kindDateTime,
+	"TIME":       kindTime,
+	"YEAR":       kindYear,
+	"JSON":       kindJSON,
+	"ENUM":       kindEnum,
+	"SET":        kindSet,
+}
+
+// columnKindOf 获取列的类型分类,驱动根据列的unsigned标志在类型名称前添加UNSIGNED,比如 UNSIGNED BIGINT
+func columnKindOf(columnType *sql.ColumnType) columnKind {
+	name := columnType.DatabaseTypeName()
+	if strings.HasPrefix(name, "UNSIGNED ") {
+		if columnKindMap[strings.TrimPrefix(name, "UNSIGNED ")] == kindInt {
+			return kindUint
+		}
+	}
+	return columnKindMap[name]
+}
+
+// 日期时间单元格默认的数字格式,可以通过--col-num-format修改
+const (
+	defaultDateFormat     = "yyyy-mm-dd"
+	defaultDateTimeFormat = "yyyy-mm-dd hh:mm:ss"
+	defaultTimeFormat     = "[h]:mm:ss"
+)
+
+// maxExcelScale Excel的时间格式最多显示3位小数秒,单元格的值仍然保留全部小数秒
+const maxExcelScale = 3
+
+// timeScale 时间类型的小数秒位数,比如 DATETIME(6)、TIME(6) 为6
+func timeScale(columnType *sql.ColumnType) int {
+	_, scale, ok := columnType.DecimalSize()
+	if !ok || scale <= 0 {
+		return 0
+	}
+	return int(scale)
+}
+
+// dateText 日期时间转为文本,保留小数秒,零值日期原样输出,比如 0000-00-00 00:00:00
+func dateText(t time.Time, dateOnly bool, scale int) string {
+	layout := time.DateTime
+	if dateOnly {
+		layout = time.DateOnly
+	} else if scale > 0 {
+		layout += "." + strings.Repeat("0", scale)
+	}
+	if t.IsZero() {
+		return strings.Map(zeroDigit, layout)
+	}
+	return t.Format(layout)
+}
+
+// parseDuration 解析TIME的值,格式为 [-]HHH:MM:SS[.ffffff],范围为 -838:59:59 到 838:59:59
+func parseDuration(value string) (time.Duration, error) {
+	text, negative := strings.CutPrefix(value, "-")
+	parts := strings.Split(text, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time: %s", value)
+	}
+	seconds, frac, _ := strings.Cut(parts[2], ".")
+	if len(frac) > 9 {
+		return 0, fmt.Errorf("invalid time: %s", value)
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		n := seconds
+		if i < 2 {
+			n = parts[i]
+		}
+		v, err := strconv.Atoi(n)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid time: %s", value)
+		}
+		d += time.Duration(v) * unit
+	}
+	if frac != "" {
+		ns, err := strconv.Atoi(frac + strings.Repeat("0", 9-len(frac)))
+		if err != nil || ns < 0 {
+			return 0, fmt.Errorf("invalid time: %s", value)
+		}
+		d += time.Duration(ns)
+	}
+
+	if negative {
+		d = -d
+	}
+	return d, nil
+}
+
+// durationParts 将时长拆分为符号、小时、分钟、秒和scale位小数秒
+func durationParts(d time.Duration, scale int) (sign string, h, m, s int64, frac string) {
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	h = int64(d / time.Hour)
+	m = int64(d % time.Hour / time.Minute)
+	s = int64(d % time.Minute / time.Second)
+	if scale > 9 {
+		scale = 9
+	}
+	if scale > 0 {
+		frac = "." + fmt.Sprintf("%09d", d%time.Second)[:scale]
+	}
+	return
+}
+
+// clockText 时长转为与MySQL一致的文本,比如 -838:59:59.000000
+func clockText(d time.Duration, scale int) string {
+	sign, h, m, s, frac := durationParts(d, scale)
+	return fmt.Sprintf("%s%02d:%02d:%02d%s", sign, h, m, s, frac)
+}
+
+// isoDuration 时长转为ISO 8601格式,比如 -PT838H59M59.000000S,小时数可以超过24
+func isoDuration(d time.Duration, scale int) string {
+	sign, h, m, s, frac := durationParts(d, scale)
+	return fmt.Sprintf("%sPT%dH%dM%d%sS", sign, h, m, s, frac)
+}
+
+// timeText TIME在文本格式中转为ISO 8601格式的时长,无法解析时原样输出
+// 小数秒的位数至少与原始值一致,避免列类型中没有返回小数秒位数时丢失精度
+func timeText(value string, scale int) string {
+	d, err := parseDuration(value)
+	if err != nil {
+		return value
+	}
+	if _, frac, ok := strings.Cut(value, "."); ok && len(frac) > scale {
+		scale = len(frac)
+	}
+	return isoDuration(d, scale)
+}
+
+// excelDays 电子表格中的时间为天数
+func excelDays(d time.Duration) float64 {
+	return float64(d) / float64(24*time.Hour)
+}
+
+// fracFormat 小数秒的数字格式,比如 .000
+func fracFormat(scale int) string {
+	if scale <= 0 {
+		return ""
+	}
+	if scale > maxExcelScale {
+		scale = maxExcelScale
+	}
+	return "." + strings.Repeat("0", scale)
+}
+
+// maxDecimalPrecision DECIMAL最大的精度
+const maxDecimalPrecision = 65
+
+// decimalSize DECIMAL的精度和小数位数,没有返回时为 65,30
+// 驱动根据列长度计算精度并减去符号位,DECIMAL UNSIGNED的列长度不包含符号位,驱动也没有返回unsigned标志,
+// 所以精度多声明一位,保证无符号的值不会溢出,最大为65
+func decimalSize(columnType *sql.ColumnType) (precision, scale int64) {
+	precision, scale, ok := columnType.DecimalSize()
+	if !ok {
+		return maxDecimalPrecision, 30
+	}
+	precision++
+	if precision > maxDecimalPrecision {
+		precision = maxDecimalPrecision
+	}
+	return precision, scale
+}
+
+// decimalPolicyList --decimal-policy 支持的值
+var decimalPolicyList = []string{"auto", "number", "text"}
+
+// maxExactDigits 电子表格中的数字为双精度浮点数,最多可以精确表示15位有效数字
+const maxExactDigits = 15
+
+// decimalCell 按照--decimal-policy转换DECIMAL,能精确表示时转为数字,否则保留为文本,避免金额等数据丢失精度
+// auto: 有效数字不超过15位时为数字,否则为文本; number: 总是转为数字; text: 总是保留为文本
+func decimalCell(value, policy string) (any, error) {
+	if policy == "text" || policy == "auto" && significantDigits(value) > maxExactDigits {
+		return value, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// intCell 转换有符号整数,有效数字超过15位的值写入电子表格后会被当作双精度浮点数丢失精度,按照--decimal-policy处理
+// auto和text: 保留为文本; number: 仍然写入为数字
+func intCell(value, policy string) (any, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if policy != "number" && significantDigits(value) > maxExactDigits {
+		return value, nil
+	}
+	return n, nil
+}
+
+// uintCell 转换无符号整数,与intCell一样按照--decimal-policy处理,超过int64范围的值只能写入为浮点数
+func uintCell(value, policy string) (any, error) {
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if policy != "number" && significantDigits(value) > maxExactDigits {
+		return value, nil
+	}
+	if n <= math.MaxInt64 {
+		return int64(n), nil
+	}
+	return float64(n), nil
+}
+
+// significantDigits 十进制字符串的有效数字位数,不包含符号、小数点、开头和结尾的0
+func significantDigits(value string) int {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+	digits = strings.TrimLeft(digits, "0")
+	if strings.Contains(value, ".") {
+		digits = strings.TrimRight(digits, "0")
+	}
+	return len(digits)
+}
+
+// numericPattern 可以转为数字的字符串,不包含科学计数法、十六进制、inf等ParseFloat支持的其他写法
+var numericPattern = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?$`)
+
+// numericCell 转换--numeric-cols指定的字符串列,是数字时与DECIMAL一样按照--decimal-policy处理,否则保留为文本
+func numericCell(value, policy string) (any, error) {
+	if !numericPattern.MatchString(value) {
+		return value, nil
+	}
+	return decimalCell(value, policy)
+}
+
+// isNumericCol 判断第index列(从0开始)是否在--numeric-cols中,列名称不区分大小写,列序号从1开始
+func (m *MySQL) isNumericCol(index int, name string) bool {
+	for _, col := range strings.Split(m.numericCols, ",") {
+		col = strings.TrimSpace(col)
+		if col != "" && (col == strconv.Itoa(index+1) || strings.EqualFold(col, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// bitFormatList --bit-format 支持的值
+var bitFormatList = []string{"int", "binary"}
+
+// setFormatList --set-format 支持的值
+var setFormatList = []string{"list", "onehot"}
+
+// bitCell 转换BIT,BIT(1)转为布尔值,其他按照--bit-format转为整数或者二进制字符串,bits未知时为0
+func bitCell(value []byte, bits int, format, policy string) (any, error) {
+	n := new(big.Int).SetBytes(value)
+	if bits == 1 {
+		return n.Sign() != 0, nil
+	}
+	if format == "binary" {
+		if bits <= 0 {
+			bits = len(value) * 8
+		}
+		text := n.Text(2)
+		if len(text) < bits {
+			text = strings.Repeat("0", bits-len(text)) + text
+		}
+		return text, nil
+	}
+	return uintCell(n.String(), policy)
+}
+
+// setCell 转换SET,MySQL返回以逗号分隔的值,按照--set-delimiter重新连接
+func setCell(value, delimiter string) string {
+	if delimiter == "," {
+		return value
+	}
+	return strings.ReplaceAll(value, ",", delimiter)
+}
+
+// checkDecimalPolicy 检查--decimal-policy
+func checkDecimalPolicy(policy string) error {
+	if !in(policy, decimalPolicyList) {
+		return fmt.Errorf("unsupported --decimal-policy: %s, supported values: %s", policy, strings.Join(decimalPolicyList, ", "))
+	}
+	return nil
+}
+
+// checkColumnFormat 检查--bit-format和--set-format
+func checkColumnFormat(bitFormat, setFormat string) error {
+	if !in(bitFormat, bitFormatList) {
+		return fmt.Errorf("unsupported --bit-format: %s, supported values: %s", bitFormat, strings.Join(bitFormatList, ", "))
+	}
+	if !in(setFormat, setFormatList) {
+		return fmt.Errorf("unsupported --set-format: %s, supported values: %s", setFormat, strings.Join(setFormatList, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIntCell(t *testing.T) {
+	tests := []struct {
+		value  string
+		policy string
+		want   any
+	}{
+		{"123", "auto", int64(123)},
+		{"-123", "text", int64(-123)},
+		{"999999999999999", "auto", int64(999999999999999)},
+		{"-999999999999999", "auto", int64(-999999999999999)},
+
+		// 2^53+1无法用双精度浮点数精确表示
+		{"9007199254740993", "auto", "9007199254740993"},
+		{"-9007199254740993", "text", "-9007199254740993"},
+		{"9007199254740993", "number", int64(9007199254740993)},
+		{"9223372036854775807", "auto", "9223372036854775807"},
+		{"-9223372036854775808", "auto", "-9223372036854775808"},
+	}
+	for _, tt := range tests {
+		got, err := intCell(tt.value, tt.policy)
+		if err != nil {
+			t.Fatalf("intCell(%q, %q) error: %v", tt.value, tt.policy, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("intCell(%q, %q) = %#v, want %#v", tt.value, tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestUintCell(t *testing.T) {
+	tests := []struct {
+		value  string
+		policy string
+		want   any
+	}{
+		{"123", "auto", int64(123)},
+		{"999999999999999", "text", int64(999999999999999)},
+		{"9007199254740993", "auto", "9007199254740993"},
+		{"9007199254740993", "number", int64(9007199254740993)},
+		{"18446744073709551615", "auto", "18446744073709551615"},
+		{"18446744073709551615", "number", float64(18446744073709551615)},
+	}
+	for _, tt := range tests {
+		got, err := uintCell(tt.value, tt.policy)
+		if err != nil {
+			t.Fatalf("uintCell(%q, %q) error: %v", tt.value, tt.policy, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("uintCell(%q, %q) = %#v, want %#v", tt.value, tt.policy, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind SQL词法单元的类型
+type tokenKind int
+
+const (
+	tokenWord     tokenKind = iota // 关键字、标识符和数字
+	tokenString                    // 字符串,包括单引号和双引号
+	tokenIdent                     // 反引号标识符
+	tokenVariable                  // 用户变量和系统变量,比如 @a、@@version
+	tokenSymbol                    // 运算符和标点符号
+)
+
+// token SQL词法单元
+type token struct {
+	kind tokenKind
+	text string // 原始文本
+	pos  int    // 在SQL中的位置
+}
+
+// is 判断是否为指定的关键字或符号,不区分大小写
+func (t token) is(s string) bool {
+	return (t.kind == tokenWord || t.kind == tokenSymbol) && strings.EqualFold(t.text, s)
+}
+
+// tokenize 将SQL拆分为词法单元,注释和优化器提示会被忽略,/*! */ 中的内容会被MySQL执行,按照普通SQL处理
+func tokenize(query string) []token {
+	return tokenizeEscape(query, true)
+}
+
+// tokenizeEscape 与tokenize相同,backslashEscapes为false时字符串中的反斜杠不是转义字符
+func tokenizeEscape(query string, backslashEscapes bool) []token {
+	var tokens []token
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case isSpace(c):
+			i++
+
+		// 注释
+		case strings.HasPrefix(query[i:], "--") && (i+2 == len(query) || isSpace(query[i+2])), c == '#':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end
+		case strings.HasPrefix(query[i:], "/*!"):
+			// 跳过版本号,比如 /*!50000
+			i += 3
+			for i < len(query) && query[i] >= '0' && query[i] <= '9' {
+				i++
+			}
+		case strings.HasPrefix(query[i:], "*/"):
+			// /*! */ 的结束标记
+			i += 2
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+
+		// 字符串和标识符
+		case c == '\'' || c == '"':
+			end := scanQuotedEscape(query, i, backslashEscapes)
+			tokens = append(tokens, token{kind: tokenString, text: query[i:end], pos: i})
+			i = end
+		case c == '`':
+			end := scanQuoted(query, i)
+			tokens = append(tokens, token{kind: tokenIdent, text: query[i:end], pos: i})
+			i = end
+
+		// 变量
+		case c == '@':
+			end := i + 1
+			if end < len(query) && query[end] == '@' {
+				end++
+			}
+			if end < len(query) && (query[end] == '\'' || query[end] == '"' || query[end] == '`') {
+				end = scanQuoted(query, end)
+			} else {
+				for end < len(query) && (isWordChar(query[end]) || query[end] == '.') {
+					end++
+				}
+			}
+			tokens = append(tokens, token{kind: tokenVariable, text: query[i:end], pos: i})
+			i = end
+
+		// 关键字、标识符和数字
+		case isWordChar(c):
+			end := i + 1
+			for end < len(query) && isWordChar(query[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: query[i:end], pos: i})
+			i = end
+
+		// 运算符
+		default:
+			end := i + 1
+			for _, op := range []string{":=", "<=>", "<=", ">=", "<>", "!=", "->>", "->", "<<", ">>", "&&", "||"} {
+				if strings.HasPrefix(query[i:], op) {
+					end = i + len(op)
+					break
+				}
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: query[i:end], pos: i})
+			i = end
+		}
+	}
+	return tokens
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '$' || c >= 0x80
+}
+
+// validateReadOnly 校验SQL是否为只读语句,仅仅为了安全考虑
+// 允许 SELECT、WITH、TABLE、SHOW、DESCRIBE、EXPLAIN,不允许加锁读、INTO OUTFILE/DUMPFILE、变量赋值和多条语句
+// 字符串中的反斜杠是否为转义字符取决于服务端的sql_mode(NO_BACKSLASH_ESCAPES),所以两种方式都必须通过校验,
+// 比如 'a\' INTO OUTFILE '/tmp/x' -- ' 在NO_BACKSLASH_ESCAPES时字符串在第二个单引号处结束
+func validateReadOnly(query string) error {
+	for _, backslashEscapes := range []bool{true, false} {
+		err := validateTokens(query, tokenizeEscape(query, backslashEscapes))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTokens 按照一种字符串转义方式校验SQL是否为只读语句
+func validateTokens(query string, tokens []token) error {
+	// 多条语句,允许结尾的分号
+	for len(tokens) > 0 && tokens[len(tokens)-1].is(";") {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("the sql statement is empty")
+	}
+	for i, t := range tokens {
+		if t.is(";") {
+			return tokenError(query, tokens[i+1], "multiple statements are not allowed")
+		}
+	}
+
+	// 第一个关键字决定语句类型,允许以括号开头,比如 (SELECT ...) UNION (SELECT ...)
+	first := tokens[0]
+	for _, t := range tokens {
+		if !t.is("(") {
+			first = t
+			break
+		}
+	}
+	switch strings.ToUpper(first.text) {
+	case "SELECT", "WITH", "TABLE":
+	case "SHOW":
+		return nil
+	case "DESCRIBE", "DESC", "EXPLAIN":
+		// EXPLAIN ANALYZE会真正执行语句,其他情况只会查看执行计划
+		if len(tokens) < 2 || !tokens[1].is("ANALYZE") {
+			return nil
+		}
+	default:
+		return tokenError(query, first, "only SELECT, WITH, TABLE, SHOW, DESCRIBE and EXPLAIN statements are allowed")
+	}
+
+	for i, t := range tokens {
+		// 变量赋值
+		if t.is(":=") {
+			return tokenError(query, t, "variable assignment is not allowed")
+		}
+
+		// 限定名中的关键字是普通标识符,比如 t.update
+		if t.kind != tokenWord || i > 0 && tokens[i-1].is(".") {
+			continue
+		}
+		next := func(n int) token {
+			if i+n < len(tokens) {
+				return tokens[i+n]
+			}
+			return token{}
+		}
+
+		switch strings.ToUpper(t.text) {
+		case "INTO":
+			switch {
+			case next(1).is("OUTFILE"), next(1).is("DUMPFILE"):
+				return tokenError(query, t, "INTO %s is not allowed", strings.ToUpper(next(1).text))
+			case next(1).kind == tokenVariable:
+				return tokenError(query, t, "variable assignment is not allowed")
+			default:
+				return tokenError(query, t, "SELECT ... INTO is not allowed")
+			}
+		case "FOR":
+			if next(1).is("UPDATE") || next(1).is("SHARE") {
+				return tokenError(query, t, "locking read FOR %s is not allowed", strings.ToUpper(next(1).text))
+			}
+		case "LOCK":
+			if next(1).is("IN") && next(2).is("SHARE") && next(3).is("MODE") {
+				return tokenError(query, t, "locking read LOCK IN SHARE MODE is not allowed")
+			}
+		case "UPDATE", "DELETE":
+			return tokenError(query, t, "%s statement is not allowed", strings.ToUpper(t.text))
+		case "INSERT", "REPLACE":
+			// INSERT()和REPLACE()字符串函数
+			if !next(1).is("(") {
+				return tokenError(query, t, "%s statement is not allowed", strings.ToUpper(t.text))
+			}
+		}
+	}
+
+	return nil
+}
+
+// isSelect 是否为SELECT、WITH、TABLE语句,允许以括号开头,只有这些语句可以作为派生表
+func isSelect(query string) bool {
+	for _, t := range tokenize(query) {
+		if !t.is("(") {
+			return t.is("SELECT") || t.is("WITH") || t.is("TABLE")
+		}
+	}
+	return false
+}
+
+// tokenError 生成指向出错位置的错误信息
+func tokenError(query string, t token, format string, args ...any) error {
+	before := query[:t.pos]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:]) + 1
+
+	near := strings.Join(strings.Fields(query[t.pos:]), " ")
+	if utf8.RuneCountInString(near) > 30 {
+		near = string([]rune(near)[:30]) + "..."
+	}
+
+	return fmt.Errorf("%s, near '%s' at line %d column %d", fmt.Sprintf(format, args...), near, line, column)
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"SELECT a, b FROM t", []string{"SELECT", "a", ",", "b", "FROM", "t"}},
+		{"SELECT 1 -- comment\nFROM t", []string{"SELECT", "1", "FROM", "t"}},
+		{"SELECT 1 # comment\nFROM t", []string{"SELECT", "1", "FROM", "t"}},
+		{"SELECT 1--1", []string{"SELECT", "1", "-", "-", "1"}},
+		{"SELECT /* comment */ 1", []string{"SELECT", "1"}},
+		{"SELECT /*+ MAX_EXECUTION_TIME(1000) */ 1", []string{"SELECT", "1"}},
+		{"SELECT /*!50000 1 */", []string{"SELECT", "1"}},
+		{"SELECT 'a''b', \"c\\\"d\", `e``f`", []string{"SELECT", "'a''b'", ",", `"c\"d"`, ",", "`e``f`"}},
+		{"SELECT 'a;b' ; ", []string{"SELECT", "'a;b'", ";"}},
+		{"SELECT @a, @@version, @`x y`", []string{"SELECT", "@a", ",", "@@version", ",", "@`x y`"}},
+		{"SELECT a:=1, b<=>c, d->>'$.e'", []string{"SELECT", "a", ":=", "1", ",", "b", "<=>", "c", ",", "d", "->>", "'$.e'"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, token := range tokenize(tt.query) {
+			got = append(got, token.text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizeBackslashEscapes(t *testing.T) {
+	query := `SELECT 'a\' INTO OUTFILE '/tmp/x' -- '`
+	if n := len(tokenizeEscape(query, true)); n != 7 {
+		t.Errorf("with backslash escapes got %d tokens, want 7", n)
+	}
+	if n := len(tokenizeEscape(query, false)); n != 5 {
+		t.Errorf("without backslash escapes got %d tokens, want 5", n)
+	}
+}
+
+func TestValidateReadOnly(t *testing.T) {
+	tests := []struct {
+		query string
+		ok    bool
+	}{
+		// 允许的语句
+		{"SELECT * FROM t", true},
+		{"select * from t;", true},
+		{"(SELECT 1) UNION (SELECT 2)", true},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", true},
+		{"TABLE t", true},
+		{"SHOW TABLES", true},
+		{"DESCRIBE t", true},
+		{"EXPLAIN SELECT * FROM t", true},
+
+		// 不允许的语句
+		{"", false},
+		{";", false},
+		{"UPDATE t SET a = 1", false},
+		{"DELETE FROM t", false},
+		{"INSERT INTO t VALUES (1)", false},
+		{"REPLACE INTO t VALUES (1)", false},
+		{"SELECT 1; DROP TABLE t", false},
+		{"WITH x AS (SELECT 1) DELETE FROM t", false},
+
+		// EXPLAIN只查看执行计划,EXPLAIN ANALYZE会真正执行语句
+		{"EXPLAIN DELETE FROM t", true},
+		{"EXPLAIN ANALYZE SELECT * FROM t", true},
+		{"EXPLAIN ANALYZE DELETE FROM t", false},
+
+		// 函数名称和标识符不是语句
+		{"SELECT INSERT('abc', 1, 1, 'x')", true},
+		{"SELECT insert ('abc', 1, 1, 'x')", true},
+		{"SELECT REPLACE(name, 'a', 'b') FROM t", true},
+		{"SELECT t.update, t.delete, t.insert FROM t", true},
+		{"SELECT `update` FROM t", true},
+
+		// 注释和字符串中的关键字
+		{"SELECT 1 -- DELETE FROM t", true},
+		{"SELECT 1 # INTO OUTFILE '/tmp/x'", true},
+		{"SELECT 1 /* ; DROP TABLE t */", true},
+		{"SELECT 'INTO OUTFILE', \"FOR UPDATE\" FROM t", true},
+		{"SELECT 1 /*! INTO OUTFILE '/tmp/x' */", false},
+
+		// INTO OUTFILE/DUMPFILE和变量
+		{"SELECT * FROM t INTO OUTFILE '/tmp/x'", false},
+		{"SELECT * INTO DUMPFILE '/tmp/x' FROM t", false},
+		{"SELECT a INTO @a FROM t", false},
+		{"SELECT @a := 1", false},
+
+		// NO_BACKSLASH_ESCAPES时字符串在第二个单引号处结束
+		{`SELECT 'a\' INTO OUTFILE '/tmp/x' -- '`, false},
+		{`SELECT 'a\\' FROM t`, true},
+
+		// 加锁读
+		{"SELECT * FROM t FOR UPDATE", false},
+		{"SELECT * FROM t FOR SHARE", false},
+		{"SELECT * FROM t LOCK IN SHARE MODE", false},
+		{"SELECT * FROM t WHERE a = 'FOR UPDATE'", true},
+	}
+
+	for _, tt := range tests {
+		err := validateReadOnly(tt.query)
+		if ok := err == nil; ok != tt.ok {
+			t.Errorf("validateReadOnly(%q) error = %v, want ok = %v", tt.query, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 支持的输出格式
+var formatList = []string{"xlsx", "ods", "csv", "tsv", "jsonl", "ndjson", "parquet", "sql", "html", "md", "markdown"}
+
+// 支持的压缩算法及对应的扩展名,仅适用于文本格式
+var compressExtMap = map[string]string{
+	"gzip": ".gz",
+	"zstd": ".zst",
+}
+
+// Writer 每种输出格式都需要实现的接口
+type Writer interface {
+	// SetColumns 设置列信息,并写入表头
+	SetColumns(names []string, types []*sql.ColumnType) error
+
+	// WriteRow 写入一行数据,数据为SliceScan的原始结果
+	WriteRow(row []any) error
+
+	// WriteMarker 在数据末尾写入一条提示信息,用于标记数据不完整,比如超过了--max-rows
+	WriteMarker(text string) error
+
+	// Close 保存并关闭
+	Close() error
+}
+
+// SheetWriter 支持多个工作表的Writer,多条SQL时每条SQL写入一个单独的工作表
+type SheetWriter interface {
+	Writer
+
+	// SetSheetName 设置下一次SetColumns新建的工作表名称
+	SetSheetName(name string)
+}
+
+// NewWriter 根据输出格式返回对应的Writer
+func NewWriter() (Writer, error) {
+	format, err := getFormat()
+	if err != nil {
+		return nil, err
+	}
+	my.spreadsheet = in(format, []string{"xlsx", "ods"})
+
+	// 标准输出无法拆分为多个文件
+	if isStdout(excel.output) && excel.maxWorkbookLine > 0 {
+		return nil, fmt.Errorf("the --workbook-line cannot be used when writing to stdout")
+	}
+
+	// 多条SQL时每条SQL写入一个工作表,只有电子表格格式支持
+	if len(my.statements) > 1 {
+		if !in(format, []string{"xlsx", "ods"}) {
+			return nil, fmt.Errorf("found %d sql statements, multiple sql statements are only supported by the xlsx and ods formats", len(my.statements))
+		}
+		if excel.maxWorkbookLine > 0 {
+			return nil, fmt.Errorf("the --workbook-line cannot be used with multiple sql statements")
+		}
+	}
+
+	// 压缩算法,文本格式的输出文件不包含压缩扩展名,由TextFile添加
+	compress, err = getCompress(format)
+	if err != nil {
+		return nil, err
+	}
+	output := trimCompressExt(excel.output)
+
+	// 断点续传
+	err = resume.Init(format)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不允许覆盖已经存在的文件,尽早检查,避免导出完成后才发现,拆分为多个文件时同时检查第一个文件
+	if !isStdout(excel.output) {
+		target, ext := excel.output, ""
+		if !in(format, []string{"xlsx", "ods"}) {
+			target, ext = output, compressExtMap[compress]
+		}
+		// 从检查点继续导出时只会生成 名称-N.扩展名 的文件
+		var targets []string
+		if resume.resumed <= 0 {
+			targets = append(targets, target+ext)
+		}
+		if excel.maxWorkbookLine > 0 {
+			first, err := partOutput(target, excel.maxWorkbookLine, excel.maxWorkbookLine)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, first+ext)
+		}
+		for _, t := range targets {
+			err = checkOverwrite(t)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// DECIMAL写入电子表格的方式
+	err = checkDecimalPolicy(my.decimalPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	// BIT和SET写入电子表格的方式
+	err = checkColumnFormat(my.bitFormat, my.setFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	// 资源限制
+	err = limit.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	// 重试
+	err = retry.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	// 打包
+	err = bundle.Init()
+	if err != nil {
+		return nil, err
+	}
+	if bundle.format != "" {
+		err = checkOverwrite(bundle.output)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch format {
+	case "ods":
+		ods.output = excel.output
+		ods.maxWorkbookLine = excel.maxWorkbookLine
+		ods.maxSheetLine = excel.maxSheetLine
+		err = ods.Init()
+		if err != nil {
+			return nil, err
+		}
+		return ods, nil
+	case "csv", "tsv":
+		csv.output = output
+		csv.maxFileLine = excel.maxWorkbookLine
+		err = csv.Init(format)
+		if err != nil {
+			return nil, err
+		}
+		return csv, nil
+	case "jsonl", "ndjson":
+		jsonl.output = output
+		jsonl.maxFileLine = excel.maxWorkbookLine
+		err = jsonl.Init()
+		if err != nil {
+			return nil, err
+		}
+		return jsonl, nil
+	case "parquet":
+		pq.output = output
+		pq.maxFileLine = excel.maxWorkbookLine
+		err = pq.Init()
+		if err != nil {
+			return nil, err
+		}
+		return pq, nil
+	case "sql":
+		dump.output = output
+		dump.maxFileLine = excel.maxWorkbookLine
+		err = dump.Init()
+		if err != nil {
+			return nil, err
+		}
+		return dump, nil
+	case "html":
+		page.output = output
+		page.maxFileLine = excel.maxWorkbookLine
+		err = page.Init()
+		if err != nil {
+			return nil, err
+		}
+		return page, nil
+	case "md", "markdown":
+		md.output = output
+		md.maxFileLine = excel.maxWorkbookLine
+		err = md.Init()
+		if err != nil {
+			return nil, err
+		}
+		return md, nil
+	default:
+		return excel, nil
+	}
+}
+
+// getSheetNames 获取每条SQL对应的工作表名称
+// 单条SQL直接使用--sheet-name;多条SQL时优先使用 -- sheet: Name 注释,其次是--sheet-name中逗号分隔的名称,默认为SheetN
+func getSheetNames(statements []Statement) ([]string, error) {
+	if len(statements) == 1 {
+		if statements[0].Sheet != "" {
+			return []string{statements[0].Sheet}, nil
+		}
+		return []string{excel.sheetName}, nil
+	}
+
+	var list []string
+	if excel.sheetName != "" {
+		list = strings.Split(excel.sheetName, ",")
+	}
+	if len(list) > len(statements) {
+		return nil, fmt.Errorf("the --sheet-name specifies %d names, but there are only %d sql statements", len(list), len(statements))
+	}
+
+	names := make([]string, len(statements))
+	exists := make(map[string]bool)
+	for i, statement := range statements {
+		name := statement.Sheet
+		if name == "" && i < len(list) {
+			name = strings.TrimSpace(list[i])
+		}
+		if name == "" {
+			name = "Sheet" + strconv.Itoa(i+1)
+		}
+
+		// Excel中工作表名称不区分大小写
+		key := strings.ToLower(name)
+		if exists[key] {
+			return nil, fmt.Errorf("duplicate sheet name: %s", name)
+		}
+		exists[key] = true
+		names[i] = name
+	}
+	return names, nil
+}
+
+// getFormat 获取输出格式,未指定--format时根据输出文件的扩展名判断,默认为xlsx
+func getFormat() (string, error) {
+	if format == "" {
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(trimCompressExt(excel.output)), "."))
+		if in(ext, formatList) {
+			return ext, nil
+		}
+		return "xlsx", nil
+	}
+
+	value := strings.ToLower(format)
+	if !in(value, formatList) {
+		return "", fmt.Errorf("unsupported output format: %s, supported values: %s", format, strings.Join(formatList, ","))
+	}
+	return value, nil
+}
+
+// getCompress 获取压缩算法,未指定--compress时根据输出文件的扩展名判断
+func getCompress(format string) (string, error) {
+	value := strings.ToLower(compress)
+	if value == "" {
+		for name, ext := range compressExtMap {
+			if strings.HasSuffix(strings.ToLower(excel.output), ext) {
+				value = name
+			}
+		}
+	}
+	if value == "" {
+		return "", nil
+	}
+
+	if _, ok := compressExtMap[value]; !ok {
+		return "", fmt.Errorf("unsupported compress: %s, supported values: gzip,zstd", compress)
+	}
+	if in(format, []string{"xlsx", "ods", "parquet"}) {
+		return "", fmt.Errorf("the --compress can only be used for text formats, %s is already compressed", format)
+	}
+	return value, nil
+}
+
+// trimCompressExt 去掉输出文件中的压缩扩展名,比如 data.csv.gz => data.csv
+func trimCompressExt(output string) string {
+	for _, ext := range compressExtMap {
+		if strings.HasSuffix(strings.ToLower(output), ext) {
+			return output[:len(output)-len(ext)]
+		}
+	}
+	return output
+}
+
+// isStdout -o - 代表输出到标准输出
+func isStdout(output string) bool {
+	return output == "-"
+}
+
+// partOutput 拆分为多个文件时的命名规则: 名称-N.扩展名
+// total为当前总共写入了多少行,max为每个文件最多允许写入多少行,均不包含表头
+func partOutput(output string, total, max int) (string, error) {
+	// 从检查点继续导出时,加上之前导出的行数,保证文件编号与一次导出完成时一致
+	total += resume.resumed
+
+	// 只有一个文件的情况下
+	if max <= 0 || total < max {
+		return output, nil
+	}
+
+	// 转为绝对路径
+	absOutput, err := filepath.Abs(output)
+	if err != nil {
+		return "", err
+	}
+
+	// 绝对路径分割为 路径 和 文件名
+	dir, fileName := filepath.Split(absOutput)
+
+	// 文件名分割为 名称 和 扩展名, 名称中允许包含.
+	outputList := strings.Split(fileName, ".")
+	name := strings.Join(outputList[:len(outputList)-1], ".")
+	ext := outputList[len(outputList)-1]
+
+	index := math.Ceil(float64(total) / float64(max))
+	indexStr := strconv.FormatFloat(index, 'f', 0, 64)
+
+	// 组合出新路径
+	newOutput := strings.Join([]string{dir, name, "-", indexStr, ".", ext}, "")
+	return newOutput, nil
+}
+
+// addOutputFile 每个输出文件保存后调用,记录到压缩包的清单中,并更新断点续传的检查点
+// 导出出错时保存的不完整的文件不会记录
+func addOutputFile(path string, rows int) error {
+	if partialOutput {
+		return nil
+	}
+	bundle.Add(path, rows)
+	return resume.Commit(path)
+}
@@ -36,20 +36,52 @@ MySQL Flags:
   -u, --user string                 specifies the MySQL user (default "root")
   -p, --password string             specifies the MySQL password
   -d, --database string             specifies the MySQL database
-  -e, --execute string              specifies the SQL command to be executed
+  -e, --execute stringArray         specifies the SQL command to be executed, - means stdin, can be specified multiple times
+      --sql-file string             specifies the file containing the SQL commands to be executed, separated by semicolons, - means stdin
+      --param stringArray           specifies the parameter bound to the ? or :name placeholder in the SQL command, format: name=[int:|float:|date:|str:]value, can be specified multiple times
       --charset string              specifies the MySQL charset (default "utf8mb4")
       --collation string            specifies the MySQL collation (default "utf8mb4_general_ci")
       --connect-timeout string      specifies the MySQL connection timeout (default "5s")
       --read-timeout string         specifies the MySQL read timeout (default "30s")
       --write-timeout string        specifies the MySQL write timeout (default "30s")
       --max-allowed-packet string   specifies the MySQL maximum allowed packet (default "16MB")
+      --max-execution-time string   specifies the maximum execution time of the SQL command on the MySQL server, e.g. 10m
+      --timezone string             specifies the time zone of the DATE and DATETIME values and the date params, e.g. UTC, Asia/Shanghai (default "Local")
+      --consistent-snapshot         specifies whether to run all queries in a read-only consistent snapshot transaction
       --batch-size int              specifies the batch size to use when executing SQL commands (default 10000)
       --delay-time string           specifies the time to delay between batches when executing SQL (default "1s")
+      --max-retries int             specifies the maximum number of consecutive retries on transient MySQL errors, such as lost connection, lock wait timeout and deadlock (default 3)
+      --retry-backoff string        specifies the time to wait before the first retry, doubled on each retry with random jitter (default "1s")
 	  
+Explain Flags:
+      --explain                     specifies whether to check the execution plan with EXPLAIN before exporting
+      --explain-max-rows int        specifies the maximum number of rows estimated to be examined per table, the query will be refused if exceeded
+      --explain-refuse string       specifies the execution plans to be refused, separated by commas: full-scan, filesort, temporary
+      --force                       specifies whether to run the query anyway when it is refused by the execution plan check
+
+Resume Flags:
+      --resume-key string           specifies the ordered unique column to page the query by, a checkpoint is written after each completed file when using --workbook-line
+      --resume                      specifies whether to resume the export from the checkpoint written by --resume-key, must be used with --workbook-line
+
+Output Flags:
+  -o, --output string               specifies the name of the output file, - means stdout
+      --format string               specifies the output format: xlsx, ods, csv, tsv, jsonl, parquet, sql, html, md (default detected from the output file extension)
+      --overwrite                   specifies whether to overwrite the existing output files
+      --compress string             specifies the compression for text formats: gzip, zstd (default detected from the output file extension)
+      --bundle string               specifies the bundle format to collect all generated files and a manifest into one archive: zip
+      --bundle-password string      specifies the password for the bundle, encrypted with AES-256
+      --max-rows int                specifies the maximum number of rows to export per SQL command, the rest will be truncated with a marker in the output
+      --max-output-size string      specifies the maximum size of the output, e.g. 100MB, the rest will be truncated with a marker in the output
+
 Excel Flags:
-  -o, --output string               specifies the name of the output Excel file
       --setup-password string       specifies the password for the Excel file
-      --sheet-name string           specifies the name of the sheet in the Excel file
+      --decimal-policy string       specifies how to write DECIMAL values to the cells: auto (number if it has at most 15 significant digits, otherwise text), number, text (default "auto")
+      --numeric-cols string         specifies the string columns to write as numbers to the cells, column names or numbers separated by commas
+      --bit-format string           specifies how to write BIT(n) values to the cells: int, binary, BIT(1) is always written as a boolean (default "int")
+      --set-format string           specifies how to write SET values to the cells: list, onehot (one boolean column per value) (default "list")
+      --set-delimiter string        specifies the delimiter of the SET values when using --set-format list (default ",")
+      --enum-dropdown               specifies whether to add a dropdown list of the allowed values to the ENUM columns in the Excel file
+      --sheet-name string           specifies the name of the sheet in the Excel file, separated by commas for multiple SQL commands
       --workbook-line int           specifies the maximum number of lines all sheet in the Excel file (default -1)
       --sheet-line int              specifies the maximum number of lines per sheet in the Excel file (default 1000000)	  
       --row-height string           specifies the row height in the Excel file
@@ -60,7 +92,27 @@ Excel Flags:
       --col-align string            specifies the column alignment in the Excel file
       --col-bg-color string         specifies the column background color in the Excel file
       --col-font-color string       specifies the column font color in the Excel file
-      --col-font-size string        specifies the column font size in the Excel file`
+      --col-font-size string        specifies the column font size in the Excel file
+      --col-num-format stringArray  specifies the column number format in the Excel file, e.g. 3:yyyy-mm-dd hh:mm, can be specified multiple times
+
+CSV Flags:
+      --delimiter string            specifies the field delimiter in the CSV/TSV file (default "," for csv, "\t" for tsv)
+      --quote string                specifies the quote character in the CSV/TSV file (default "\"")
+      --line-ending string          specifies the line ending in the CSV/TSV file: lf, crlf (default "lf")
+      --bom                         specifies whether to write the UTF-8 BOM in the CSV/TSV file
+
+Parquet Flags:
+      --parquet-compression string  specifies the compression codec in the Parquet file: none, snappy, gzip, zstd (default "snappy")
+      --parquet-row-group-size string
+                                    specifies the row group size in the Parquet file (default "64MB")
+
+SQL Flags:
+      --sql-table string            specifies the table name in the INSERT statements (default the name of the output file)
+      --sql-batch-size int          specifies the number of rows per INSERT statement (default 100)
+      --sql-create-table            specifies whether to generate the CREATE TABLE statement
+
+Markdown Flags:
+      --md-max-width int            specifies the maximum width of the cells in the Markdown table, longer values will be truncated`
 		fmt.Println(msg)
 		os.Exit(0)
 	})
@@ -204,6 +204,18 @@ func (l *LogConfig) output() (zapcore.WriteSyncer, error) {
 	return zapcore.NewMultiWriteSyncer(writeSyncers...), nil
 }
 
+// UseStderr 将日志输出中的stdout替换为stderr,需要在Initialize之前调用
+func UseStderr() {
+	var outputs []string
+	for _, out := range strings.Split(viper.GetString("settings.log.output"), ",") {
+		if out == "stdout" {
+			out = "stderr"
+		}
+		outputs = append(outputs, out)
+	}
+	viper.Set("settings.log.output", strings.Join(outputs, ","))
+}
+
 func Debug(msg string, fields ...zap.Field) {
 	DefaultLogger.Debug(msg, fields...)
 }
@@ -228,6 +240,17 @@ func Panic(msg string, fields ...zap.Field) {
 	DefaultLogger.Panic(msg, fields...)
 }
 
+// fatalHooks Fatal结束程序之前执行的清理函数
+var fatalHooks []func()
+
+// OnFatal 注册Fatal结束程序之前执行的清理函数,比如删除临时文件
+func OnFatal(f func()) {
+	fatalHooks = append(fatalHooks, f)
+}
+
 func Fatal(msg string, fields ...zap.Field) {
+	for _, f := range fatalHooks {
+		f()
+	}
 	DefaultLogger.Fatal(msg, fields...)
 }
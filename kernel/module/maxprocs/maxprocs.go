@@ -21,5 +21,5 @@ func (p *AutoMaxProcs) Initialize(*cobra.Command) error {
 }
 
 func (p *AutoMaxProcs) logFunc(format string, v ...any) {
-	logger.Info(fmt.Sprintf(format, v))
+	logger.Info(fmt.Sprintf(format, v...))
 }
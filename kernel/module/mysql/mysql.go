@@ -1,7 +1,12 @@
 package mysql
 
 import (
+	"context"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -17,6 +22,15 @@ import (
 
 var DB *sqlx.DB
 
+// Conn 开启一致性快照时执行查询的专用连接
+var Conn *sqlx.Conn
+
+// Location 读取DATE、DATETIME、TIMESTAMP以及绑定日期参数时使用的时区,由--timezone指定
+var Location = time.Local
+
+// Snapshot 一致性快照对应的binlog位置
+var Snapshot *BinlogPosition
+
 var (
 	defaultHostKey   = "settings.mysql.host"
 	defaultHostValue = "127.0.0.1"
@@ -50,6 +64,15 @@ var (
 
 	defaultMaxAllowedPacketKey   = "settings.mysql.max_allowed_packet"
 	defaultMaxAllowedPacketValue = "16MB"
+
+	defaultMaxExecutionTimeKey   = "settings.mysql.max_execution_time"
+	defaultMaxExecutionTimeValue = ""
+
+	defaultTimezoneKey   = "settings.mysql.timezone"
+	defaultTimezoneValue = "Local"
+
+	defaultConsistentSnapshotKey   = "settings.mysql.consistent_snapshot"
+	defaultConsistentSnapshotValue = false
 )
 
 // MySQL implement the Module interface
@@ -72,6 +95,9 @@ func (m *MySQL) Register(cmd *cobra.Command) {
 		viper.SetDefault(defaultReadtimeoutKey, defaultReadtimeoutValue)
 		viper.SetDefault(defaultWritetimeoutKey, defaultWritetimeoutValue)
 		viper.SetDefault(defaultMaxAllowedPacketKey, defaultMaxAllowedPacketValue)
+		viper.SetDefault(defaultMaxExecutionTimeKey, defaultMaxExecutionTimeValue)
+		viper.SetDefault(defaultTimezoneKey, defaultTimezoneValue)
+		viper.SetDefault(defaultConsistentSnapshotKey, defaultConsistentSnapshotValue)
 		return
 	}
 
@@ -87,6 +113,9 @@ func (m *MySQL) Register(cmd *cobra.Command) {
 	cmd.PersistentFlags().String("read-timeout", defaultReadtimeoutValue, "specifies the MySQL read timeout")
 	cmd.PersistentFlags().String("write-timeout", defaultWritetimeoutValue, "specifies the MySQL write timeout")
 	cmd.PersistentFlags().String("max-allowed-packet", defaultMaxAllowedPacketValue, "specifies the MySQL maximum allowed packet")
+	cmd.PersistentFlags().String("max-execution-time", defaultMaxExecutionTimeValue, "specifies the maximum execution time of the SQL command on the MySQL server, e.g. 10m")
+	cmd.PersistentFlags().String("timezone", defaultTimezoneValue, "specifies the time zone of the DATE and DATETIME values and the date params, e.g. UTC, Asia/Shanghai")
+	cmd.PersistentFlags().Bool("consistent-snapshot", defaultConsistentSnapshotValue, "specifies whether to run all queries in a read-only consistent snapshot transaction")
 
 	// bind
 	err := viper.BindPFlag(defaultHostKey, cmd.PersistentFlags().Lookup("host"))
@@ -133,6 +162,18 @@ func (m *MySQL) Register(cmd *cobra.Command) {
 	if err != nil {
 		panic(err)
 	}
+	err = viper.BindPFlag(defaultMaxExecutionTimeKey, cmd.PersistentFlags().Lookup("max-execution-time"))
+	if err != nil {
+		panic(err)
+	}
+	err = viper.BindPFlag(defaultTimezoneKey, cmd.PersistentFlags().Lookup("timezone"))
+	if err != nil {
+		panic(err)
+	}
+	err = viper.BindPFlag(defaultConsistentSnapshotKey, cmd.PersistentFlags().Lookup("consistent-snapshot"))
+	if err != nil {
+		panic(err)
+	}
 }
 
 func (m *MySQL) MustCheck(*cobra.Command) {}
@@ -159,6 +200,24 @@ func (m *MySQL) Initialize(cmd *cobra.Command) error {
 		os.Exit(1)
 	}
 
+	// max_execution_time, the driver sets the system variable on every new connection
+	if value := viper.GetString(defaultMaxExecutionTimeKey); value != "" {
+		maxExecutionTime, err := time.ParseDuration(value)
+		if err != nil || maxExecutionTime < time.Millisecond {
+			logger.Error("the max_execution_time parameter must be a duration of at least 1ms, e.g. 10m")
+			os.Exit(1)
+		}
+		params["max_execution_time"] = strconv.FormatInt(maxExecutionTime.Milliseconds(), 10)
+	}
+
+	// time zone, Local is the system time zone
+	loc, err := time.LoadLocation(viper.GetString(defaultTimezoneKey))
+	if err != nil {
+		logger.Error("the timezone parameter is invalid", zap.Error(err))
+		os.Exit(1)
+	}
+	Location = loc
+
 	// build configuration
 	mysqlConfig := mysql.Config{
 		User:                 viper.GetString(defaultUserKey),
@@ -168,7 +227,7 @@ func (m *MySQL) Initialize(cmd *cobra.Command) error {
 		DBName:               viper.GetString(defaultDatabaseKey),
 		Params:               params,
 		Collation:            viper.GetString(defaultCollationKey),
-		Loc:                  time.Local,
+		Loc:                  Location,
 		ParseTime:            true,
 		Timeout:              viper.GetDuration(defaultConntimeoutKey),
 		ReadTimeout:          viper.GetDuration(defaultReadtimeoutKey),
@@ -201,6 +260,201 @@ func (m *MySQL) Initialize(cmd *cobra.Command) error {
 	return nil
 }
 
+// Queryer 执行查询的对象,开启一致性快照时为专用连接,否则为连接池
+func Queryer() sqlx.QueryerContext {
+	if Conn != nil {
+		return Conn
+	}
+	return DB
+}
+
+// BinlogPosition binlog位置
+type BinlogPosition struct {
+	File         string `json:"binlog_file,omitempty"`
+	Position     uint64 `json:"binlog_position,omitempty"`
+	GTIDExecuted string `json:"gtid_executed,omitempty"`
+	Exact        bool   `json:"exact"` // 是否与快照完全一致
+}
+
+// String 快照位置的文本,记录在电子表格的文档属性中
+func (p *BinlogPosition) String() string {
+	var list []string
+	if p.File != "" {
+		list = append(list, "binlog_file="+p.File, "binlog_position="+strconv.FormatUint(p.Position, 10))
+	}
+	if p.GTIDExecuted != "" {
+		list = append(list, "gtid_executed="+p.GTIDExecuted)
+	}
+	list = append(list, "exact="+strconv.FormatBool(p.Exact))
+	return strings.Join(list, ", ")
+}
+
+// StartSnapshot 开启--consistent-snapshot时,在专用连接上以REPEATABLE READ隔离级别开启只读的一致性快照事务
+// 之后所有的查询都在该事务中执行,读取到的是同一时间点的数据
+func StartSnapshot(ctx context.Context) error {
+	if !viper.GetBool(defaultConsistentSnapshotKey) {
+		return nil
+	}
+
+	conn, err := DB.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	for _, query := range []string{
+		"SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+		"START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		if err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+	Conn = conn
+
+	// 获取binlog位置,失败时不影响导出
+	Snapshot, err = snapshotPosition(ctx, conn)
+	if err != nil {
+		logger.Warn("get the binlog position of the consistent snapshot error", zap.Error(err))
+		logger.Info("consistent snapshot started")
+		return nil
+	}
+	logger.Info("consistent snapshot started",
+		zap.String("binlog_file", Snapshot.File),
+		zap.Uint64("binlog_position", Snapshot.Position),
+		zap.String("gtid_executed", Snapshot.GTIDExecuted),
+		zap.Bool("exact", Snapshot.Exact),
+	)
+	if !Snapshot.Exact {
+		logger.Warn("the binlog position is read after the consistent snapshot started, it may be inexact if there are concurrent writes")
+	}
+
+	return nil
+}
+
+// EndSnapshot 结束一致性快照事务并释放专用连接
+func EndSnapshot() error {
+	if Conn == nil {
+		return nil
+	}
+	_, err := Conn.ExecContext(context.Background(), "COMMIT")
+	closeErr := Conn.Close()
+	Conn = nil
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// Reconnect 连接断开后重新连接,连接池会丢弃已经断开的连接并建立新的连接
+// 一致性快照的专用连接断开后事务已经结束,无法恢复
+func Reconnect(ctx context.Context) error {
+	if Conn != nil {
+		return fmt.Errorf("the consistent snapshot connection cannot be reconnected")
+	}
+	return DB.PingContext(ctx)
+}
+
+// IsTransient 是否为重试后可能成功的临时错误,比如连接断开、锁等待超时、死锁
+// 驱动不会以MySQLError返回2006、2013等客户端错误码,连接断开时返回ErrInvalidConn、ErrBadConn或者网络错误
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1040, // Too many connections
+			1053, // Server shutdown in progress
+			1205, // Lock wait timeout exceeded
+			1213: // Deadlock found when trying to get lock
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// snapshotPosition 获取一致性快照对应的binlog位置
+// MariaDB和Percona Server可以通过状态变量获取快照对应的准确位置,MySQL只能在开启快照后立即获取当前位置
+func snapshotPosition(ctx context.Context, conn *sqlx.Conn) (*BinlogPosition, error) {
+	status := make(map[string]string)
+	rows, err := conn.QueryxContext(ctx, "SHOW SESSION STATUS LIKE 'binlog_snapshot_%'")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var name, value string
+		err = rows.Scan(&name, &value)
+		if err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		status[strings.ToLower(name)] = value
+	}
+	_ = rows.Close()
+
+	if status["binlog_snapshot_file"] != "" {
+		position, err := strconv.ParseUint(status["binlog_snapshot_position"], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &BinlogPosition{
+			File:         status["binlog_snapshot_file"],
+			Position:     position,
+			GTIDExecuted: status["binlog_snapshot_gtid_executed"],
+			Exact:        true,
+		}, nil
+	}
+
+	// MySQL 8.4 使用 SHOW BINARY LOG STATUS 替代了 SHOW MASTER STATUS
+	for _, query := range []string{"SHOW MASTER STATUS", "SHOW BINARY LOG STATUS"} {
+		rows, err = conn.QueryxContext(ctx, query)
+		if err != nil {
+			continue
+		}
+		value := make(map[string]any)
+		if rows.Next() {
+			err = rows.MapScan(value)
+		}
+		_ = rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(value) == 0 {
+			return nil, fmt.Errorf("the binary log is not enabled")
+		}
+
+		position, err := strconv.ParseUint(toString(value["Position"]), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &BinlogPosition{
+			File:         toString(value["File"]),
+			Position:     position,
+			GTIDExecuted: toString(value["Executed_Gtid_Set"]),
+		}, nil
+	}
+	return nil, err
+}
+
+func toString(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
 func (m *MySQL) allow(cmd *cobra.Command) bool {
 	for _, use := range m.AllowedCommands {
 		if use == cmd.Use {